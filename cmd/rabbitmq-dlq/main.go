@@ -0,0 +1,275 @@
+// Command rabbitmq-dlq inspects and replays the messages of a RabbitMQ dead-letter queue.
+//
+// Usage:
+//
+//	rabbitmq-dlq peek   -queue orders.dlq [filters] [-limit 20] [-body 512]
+//	rabbitmq-dlq replay -queue orders.dlq [filters] [-limit 0] [-rate 10] [-to-queue q] [-to-exchange] [-dry-run]
+//
+// Filters:
+//
+//	-header key=value   match a header value (repeatable)
+//	-reason rejected    match the dead-letter reason
+//	-from-queue orders  match the queue the message was dead-lettered from
+//	-older-than 1h      match messages dead-lettered at least this long ago
+//	-newer-than 24h     match messages dead-lettered at most this long ago
+//
+// The connection URL is read from -url or the RABBITMQ_URL environment variable.
+// Peek requeues every message it reads; replay removes a message from the dead-letter
+// queue only after the broker confirms its republication.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+const usage = `usage: rabbitmq-dlq <peek|replay> -queue <dead-letter queue> [flags]
+
+Run "rabbitmq-dlq <command> -h" for the flags of each command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "peek":
+		err = peek(ctx, os.Args[2:], os.Stdout)
+	case "replay":
+		err = replay(ctx, os.Args[2:], os.Stdout)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rabbitmq-dlq: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// commonFlags are shared by every command.
+type commonFlags struct {
+	url       string
+	queue     string
+	headers   headerFlags
+	reason    string
+	fromQueue string
+	olderThan time.Duration
+	newerThan time.Duration
+	limit     int
+	flagSet   *flag.FlagSet
+}
+
+func newCommonFlags(name string, defaultLimit int, limitUsage string) *commonFlags {
+	f := &commonFlags{flagSet: flag.NewFlagSet(name, flag.ContinueOnError)}
+	fs := f.flagSet
+	fs.StringVar(&f.url, "url", os.Getenv("RABBITMQ_URL"), "AMQP connection URL (default $RABBITMQ_URL)")
+	fs.StringVar(&f.queue, "queue", "", "dead-letter queue name (required)")
+	fs.Var(&f.headers, "header", "match header `key=value` (repeatable)")
+	fs.StringVar(&f.reason, "reason", "", "match the dead-letter reason (rejected, expired, maxlen, delivery_limit)")
+	fs.StringVar(&f.fromQueue, "from-queue", "", "match the queue messages were dead-lettered from")
+	fs.DurationVar(&f.olderThan, "older-than", 0, "match messages dead-lettered at least this long ago")
+	fs.DurationVar(&f.newerThan, "newer-than", 0, "match messages dead-lettered at most this long ago")
+	fs.IntVar(&f.limit, "limit", defaultLimit, limitUsage)
+	return f
+}
+
+func (f *commonFlags) parse(args []string) error {
+	if err := f.flagSet.Parse(args); err != nil {
+		return err
+	}
+	if f.url == "" {
+		return errors.New("-url or RABBITMQ_URL is required")
+	}
+	if f.queue == "" {
+		return errors.New("-queue is required")
+	}
+	return nil
+}
+
+func (f *commonFlags) filter() rabbitmq.DeadLetterFilter {
+	return rabbitmq.DeadLetterFilter{
+		Headers:       f.headers.values,
+		Reason:        f.reason,
+		OriginalQueue: f.fromQueue,
+		OlderThan:     f.olderThan,
+		NewerThan:     f.newerThan,
+	}
+}
+
+func (f *commonFlags) open(ctx context.Context) (rabbitmq.Client, *rabbitmq.DeadLetterQueue, error) {
+	client, err := rabbitmq.NewClient(ctx, f.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	dlq, err := rabbitmq.NewDeadLetterQueue(client, f.queue)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, err
+	}
+	return client, dlq, nil
+}
+
+func peek(ctx context.Context, args []string, out io.Writer) error {
+	f := newCommonFlags("peek", 20, "maximum number of messages to show (0 shows all)")
+	bodyBytes := f.flagSet.Int("body", 512, "bytes of each body to print (0 hides bodies, -1 prints them whole)")
+	if err := f.parse(args); err != nil {
+		return err
+	}
+
+	client, dlq, err := f.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	letters, err := dlq.Peek(ctx, f.filter(), f.limit)
+	for i := range letters {
+		printDeadLetter(out, i+1, &letters[i], *bodyBytes)
+	}
+	fmt.Fprintf(out, "%d message(s) shown\n", len(letters))
+	return err
+}
+
+func replay(ctx context.Context, args []string, out io.Writer) error {
+	f := newCommonFlags("replay", 0, "maximum number of messages to replay (0 replays all matches)")
+	perSecond := f.flagSet.Float64("rate", 10, "maximum messages republished per second (0 is unlimited)")
+	toQueue := f.flagSet.String("to-queue", "", "republish to this queue instead of the original one")
+	toExchange := f.flagSet.Bool("to-exchange", false, "republish to the original exchange and routing key")
+	dryRun := f.flagSet.Bool("dry-run", false, "only list the messages that would be replayed")
+	if err := f.parse(args); err != nil {
+		return err
+	}
+
+	client, dlq, err := f.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	cfg := rabbitmq.ReplayCfg{
+		Filter:     f.filter(),
+		Limit:      f.limit,
+		PerSecond:  *perSecond,
+		Queue:      *toQueue,
+		ToExchange: *toExchange,
+	}
+
+	if *dryRun {
+		letters, err := dlq.Peek(ctx, cfg.Filter, cfg.Limit)
+		printDryRun(out, letters, cfg)
+		return err
+	}
+
+	result, err := dlq.Replay(ctx, cfg)
+	fmt.Fprintf(out, "%d message(s) replayed, %d left in %s\n", result.Replayed, result.Skipped, f.queue)
+	return err
+}
+
+func printDeadLetter(out io.Writer, n int, d *rabbitmq.DeadLetter, bodyBytes int) {
+	fmt.Fprintf(out, "#%d  id=%s  reason=%s  deaths=%d\n", n, d.MessageID, d.Reason, d.DeathCount)
+	fmt.Fprintf(out, "    from queue=%q exchange=%q routing-keys=%v\n", d.OriginalQueue, d.OriginalExchange, d.OriginalRoutingKeys)
+	if !d.DeadLetteredAt.IsZero() {
+		fmt.Fprintf(out, "    dead-lettered %s (%s ago)\n", d.DeadLetteredAt.Format(time.RFC3339), time.Since(d.DeadLetteredAt).Round(time.Second))
+	}
+
+	keys := make([]string, 0, len(d.Headers))
+	for key := range d.Headers {
+		if key != "x-death" {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "    %s: %v\n", key, d.Headers[key])
+	}
+
+	if bodyBytes != 0 {
+		body := d.Body
+		truncated := bodyBytes > 0 && len(body) > bodyBytes
+		if truncated {
+			body = body[:bodyBytes]
+		}
+		fmt.Fprintf(out, "    body: %s", body)
+		if truncated {
+			fmt.Fprintf(out, "... (%d bytes)", len(d.Body))
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out)
+}
+
+// target describes where Replay republishes d with cfg.
+// printDryRun lists what Replay would do with letters. Messages without a resolvable
+// origin are reported as skipped, as Replay leaves them in the dead-letter queue.
+func printDryRun(out io.Writer, letters []rabbitmq.DeadLetter, cfg rabbitmq.ReplayCfg) {
+	replayed, skipped := 0, 0
+	for i := range letters {
+		exchange, routingKey, ok := cfg.Target(&letters[i])
+		if !ok {
+			skipped++
+			fmt.Fprintf(out, "would skip %s: unknown origin\n", letters[i].MessageID)
+			continue
+		}
+		replayed++
+		fmt.Fprintf(out, "would replay %s -> %s\n", letters[i].MessageID, target(exchange, routingKey))
+	}
+	fmt.Fprintf(out, "%d message(s) would be replayed, %d skipped\n", replayed, skipped)
+}
+
+func target(exchange, routingKey string) string {
+	if exchange == "" {
+		return "queue " + routingKey
+	}
+	return fmt.Sprintf("exchange %s (%s)", exchange, routingKey)
+}
+
+// headerFlags collects repeated -header key=value flags.
+type headerFlags struct {
+	values map[string]string
+}
+
+func (h *headerFlags) String() string {
+	pairs := make([]string, 0, len(h.values))
+	for key, value := range h.values {
+		pairs = append(pairs, key+"="+value)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (h *headerFlags) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	if h.values == nil {
+		h.values = make(map[string]string)
+	}
+	h.values[key] = value
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+func TestPrintDryRunCountsOnlyReplayableMessages(t *testing.T) {
+	letters := []rabbitmq.DeadLetter{
+		{Message: rabbitmq.Message{MessageID: "m1"}, OriginalQueue: "orders"},
+		{Message: rabbitmq.Message{MessageID: "m2"}},
+		{Message: rabbitmq.Message{MessageID: "m3"}, OriginalExchange: "events", OriginalRoutingKeys: []string{"order.created"}},
+	}
+
+	var out strings.Builder
+	printDryRun(&out, letters, rabbitmq.ReplayCfg{ToExchange: true})
+
+	want := strings.Join([]string{
+		"would replay m1 -> queue orders",
+		"would skip m2: unknown origin",
+		"would replay m3 -> exchange events (order.created)",
+		"2 message(s) would be replayed, 1 skipped",
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Fatalf("dry run output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestPrintDryRunToQueueReplaysEverything(t *testing.T) {
+	letters := []rabbitmq.DeadLetter{{Message: rabbitmq.Message{MessageID: "m1"}}}
+
+	var out strings.Builder
+	printDryRun(&out, letters, rabbitmq.ReplayCfg{Queue: "orders.retry"})
+
+	if !strings.HasSuffix(out.String(), "1 message(s) would be replayed, 0 skipped\n") {
+		t.Fatalf("dry run output = %q, want the message counted as replayed", out.String())
+	}
+}
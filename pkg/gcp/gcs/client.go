@@ -171,6 +171,22 @@ func (c *client) PutObject(obj *Object) error {
 	return nil
 }
 
+// DeleteObject removes an object from a bucket.
+func (c *client) DeleteObject(key string) error {
+	if key == "" {
+		return fmt.Errorf("object key is empty")
+	}
+
+	if err := c.storage.Bucket(c.bucketName).Object(key).Delete(c.ctx); err != nil {
+		if err == storage.ErrObjectNotExist {
+			return fmt.Errorf("%s: %s", ErrObjectNotFound, key)
+		}
+		return fmt.Errorf("error deleting object: %w", err)
+	}
+
+	return nil
+}
+
 // GetObjectSignedURL creates a signed URL that can be used to download an object from the main bucket.
 // The signed URL is valid for the specified number of seconds.
 func (c *client) GetObjectSignedURL(objectKey, method string) (*SignedURL, error) {
@@ -44,6 +44,27 @@ type Client interface {
 	Close() error
 }
 
+// MaxBatchSize is the maximum number of time series Cloud Monitoring accepts in a
+// single CreateTimeSeries request.
+const MaxBatchSize = 200
+
+// Point is a datapoint written with WriteBatch.
+type Point struct {
+	MetricType string
+	Labels     map[string]string
+	// Start marks the interval beginning of a cumulative point. Leave it zero for a gauge.
+	Start time.Time
+	Value float64
+}
+
+// BatchWriter is implemented by clients that can write several datapoints per request.
+// The client returned by NewClient implements it.
+type BatchWriter interface {
+	// WriteBatch sends up to MaxBatchSize points, each to a different time series, in a
+	// single request.
+	WriteBatch(ctx context.Context, points []Point) error
+}
+
 type client struct {
 	project  string
 	api      *monitoring.MetricClient
@@ -100,43 +121,39 @@ func NewClient(ctx context.Context, cfg *Cfg) (Client, error) {
 	}, nil
 }
 
+var _ BatchWriter = (*client)(nil)
+
 // WriteGauge sends a single gauge datapoint to Cloud Monitoring.
 func (c *client) WriteGauge(ctx context.Context, metricType string, labels map[string]string, value float64) error {
-	return c.writePoint(ctx, metricType, labels, value, time.Time{}, metricpb.MetricDescriptor_GAUGE)
+	return c.WriteBatch(ctx, []Point{{MetricType: metricType, Labels: labels, Value: value}})
 }
 
 // WriteCumulative sends a cumulative datapoint to Cloud Monitoring; start marks the interval beginning.
 func (c *client) WriteCumulative(ctx context.Context, metricType string, labels map[string]string, start time.Time, value float64) error {
-	return c.writePoint(ctx, metricType, labels, value, start, metricpb.MetricDescriptor_CUMULATIVE)
+	if start.IsZero() {
+		return fmt.Errorf("start time is required for cumulative metrics")
+	}
+	return c.WriteBatch(ctx, []Point{{MetricType: metricType, Labels: labels, Start: start, Value: value}})
 }
 
-// Close closes the Monitoring client.
-func (c *client) Close() error {
-	if c.api == nil {
+// WriteBatch sends points to Cloud Monitoring in a single request. Points with a start
+// time are written as CUMULATIVE, the others as GAUGE.
+func (c *client) WriteBatch(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
 		return nil
 	}
-	if err := c.api.Close(); err != nil {
-		slog.Error("failed to close monitoring client", slog.String("error", err.Error()))
-		return err
+	if len(points) > MaxBatchSize {
+		return fmt.Errorf("cannot write %d points in one request, the maximum is %d", len(points), MaxBatchSize)
 	}
-	return nil
-}
 
-func (c *client) writePoint(ctx context.Context, metricType string, labels map[string]string, value float64, start time.Time, kind metricpb.MetricDescriptor_MetricKind) error {
-	if metricType == "" {
-		return fmt.Errorf("metricType cannot be empty")
-	}
-	if !strings.HasPrefix(metricType, "custom.googleapis.com/") && !strings.HasPrefix(metricType, "external.googleapis.com/") {
-		return fmt.Errorf("metricType must be a custom or external metric (custom.googleapis.com/... or external.googleapis.com/...)")
-	}
-	if kind == metricpb.MetricDescriptor_CUMULATIVE {
-		now := time.Now().UTC()
-		if start.IsZero() {
-			return fmt.Errorf("start time is required for cumulative metrics")
-		}
-		if !start.Before(now) {
-			return fmt.Errorf("start time must be before current time for cumulative metrics")
+	now := time.Now().UTC()
+	series := make([]*monitoringpb.TimeSeries, len(points))
+	for i, point := range points {
+		ts, err := c.timeSeries(point, now)
+		if err != nil {
+			return err
 		}
+		series[i] = ts
 	}
 
 	// Ensure we don't hang indefinitely on network issues; caller can override with their own deadline.
@@ -146,18 +163,53 @@ func (c *client) writePoint(ctx context.Context, metricType string, labels map[s
 		defer cancel()
 	}
 
-	metricLabels := make(map[string]string, len(labels))
-	maps.Copy(metricLabels, labels)
+	req := &monitoringpb.CreateTimeSeriesRequest{
+		Name:       c.project,
+		TimeSeries: series,
+	}
+
+	if err := c.api.CreateTimeSeries(ctx, req); err != nil {
+		return fmt.Errorf("failed to write time series: %w", err)
+	}
+	return nil
+}
 
-	now := time.Now().UTC()
+// Close closes the Monitoring client.
+func (c *client) Close() error {
+	if c.api == nil {
+		return nil
+	}
+	if err := c.api.Close(); err != nil {
+		slog.Error("failed to close monitoring client", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (c *client) timeSeries(point Point, now time.Time) (*monitoringpb.TimeSeries, error) {
+	if point.MetricType == "" {
+		return nil, fmt.Errorf("metricType cannot be empty")
+	}
+	if !strings.HasPrefix(point.MetricType, "custom.googleapis.com/") && !strings.HasPrefix(point.MetricType, "external.googleapis.com/") {
+		return nil, fmt.Errorf("metricType must be a custom or external metric (custom.googleapis.com/... or external.googleapis.com/...)")
+	}
+
+	kind := metricpb.MetricDescriptor_GAUGE
 	interval := &monitoringpb.TimeInterval{EndTime: timestamppb.New(now)}
-	if kind == metricpb.MetricDescriptor_CUMULATIVE {
-		interval.StartTime = timestamppb.New(start)
+	if !point.Start.IsZero() {
+		if !point.Start.Before(now) {
+			return nil, fmt.Errorf("start time must be before current time for cumulative metrics")
+		}
+		kind = metricpb.MetricDescriptor_CUMULATIVE
+		interval.StartTime = timestamppb.New(point.Start)
 	}
 
-	ts := &monitoringpb.TimeSeries{
+	metricLabels := make(map[string]string, len(point.Labels))
+	maps.Copy(metricLabels, point.Labels)
+
+	return &monitoringpb.TimeSeries{
 		Metric: &metricpb.Metric{
-			Type:   metricType,
+			Type:   point.MetricType,
 			Labels: metricLabels,
 		},
 		Resource:   c.resource,
@@ -167,19 +219,9 @@ func (c *client) writePoint(ctx context.Context, metricType string, labels map[s
 			{
 				Interval: interval,
 				Value: &monitoringpb.TypedValue{
-					Value: &monitoringpb.TypedValue_DoubleValue{DoubleValue: value},
+					Value: &monitoringpb.TypedValue_DoubleValue{DoubleValue: point.Value},
 				},
 			},
 		},
-	}
-
-	req := &monitoringpb.CreateTimeSeriesRequest{
-		Name:       c.project,
-		TimeSeries: []*monitoringpb.TimeSeries{ts},
-	}
-
-	if err := c.api.CreateTimeSeries(ctx, req); err != nil {
-		return fmt.Errorf("failed to write time series: %w", err)
-	}
-	return nil
+	}, nil
 }
@@ -3,22 +3,26 @@ package pubsub
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
-	//nolint:staticcheck // v1 client kept for compatibility; upgrade to v2 pending.
-	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/v2"
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Client represents a Google Cloud Pub/Sub client that manages connections and creates publishers/subscribers.
 type Client interface {
 	// NewPublisher creates a new publisher for the specified topic.
-	NewPublisher(topicID string) (Publisher, error)
+	NewPublisher(topicID string, opts ...PublisherOption) (Publisher, error)
 	// NewSubscriber creates a new subscriber for the specified subscription.
-	NewSubscriber(subscriptionID string, handler SubscriberHandler) (Subscriber, error)
+	NewSubscriber(subscriptionID string, handler SubscriberHandler, opts ...SubscriberOption) (Subscriber, error)
 	// CreateTopic creates a new topic if it doesn't exist.
 	CreateTopic(topicID string) error
 	// CreateSubscription creates a new subscription for a topic if it doesn't exist.
-	CreateSubscription(topicID, subscriptionID string) error
+	CreateSubscription(topicID, subscriptionID string, opts ...SubscriptionOption) error
 	// Close closes the Pub/Sub client.
 	Close() error
 }
@@ -27,12 +31,14 @@ type client struct {
 	pubsubClient *pubsub.Client
 	projectID    string
 	context      context.Context
+	logger       *slog.Logger
 }
 
 // Cfg holds the configuration for creating a Pub/Sub client.
 type Cfg struct {
 	ProjectID       string
-	CredentialsJSON []byte // Optional: if not provided, uses Application Default Credentials (Workload Identity)
+	CredentialsJSON []byte       // Optional: if not provided, uses Application Default Credentials (Workload Identity)
+	Logger          *slog.Logger // Optional: defaults to slog.Default(); use slog.New(slog.DiscardHandler) to silence logging
 }
 
 // NewClient creates a new Google Cloud Pub/Sub client with the given context and configuration.
@@ -47,8 +53,11 @@ func NewClient(ctx context.Context, cfg *Cfg) (Client, error) {
 
 	var opts []option.ClientOption
 	if len(cfg.CredentialsJSON) > 0 {
-		//nolint:staticcheck // WithCredentialsJSON deprecated; kept to support legacy secret format.
-		opts = append(opts, option.WithCredentialsJSON(cfg.CredentialsJSON))
+		creds, err := google.CredentialsFromJSON(ctx, cfg.CredentialsJSON, pubsub.ScopePubSub)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse credentials: %w", err)
+		}
+		opts = append(opts, option.WithCredentials(creds))
 	}
 
 	pubsubClient, err := pubsub.NewClient(ctx, cfg.ProjectID, opts...)
@@ -56,10 +65,16 @@ func NewClient(ctx context.Context, cfg *Cfg) (Client, error) {
 		return nil, fmt.Errorf("failed to create pub/sub client: %w", err)
 	}
 
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	return &client{
 		pubsubClient: pubsubClient,
 		projectID:    cfg.ProjectID,
 		context:      ctx,
+		logger:       logger,
 	}, nil
 }
 
@@ -69,15 +84,16 @@ func (c *client) CreateTopic(topicID string) error {
 		return fmt.Errorf("topic ID cannot be empty")
 	}
 
-	topic := c.pubsubClient.Topic(topicID)
-	exists, err := topic.Exists(c.context)
+	exists, err := c.topicExists(topicID)
 	if err != nil {
 		return fmt.Errorf("failed to check if topic exists: %w", err)
 	}
 
 	if !exists {
-		_, err = c.pubsubClient.CreateTopic(c.context, topicID)
-		if err != nil {
+		_, err = c.pubsubClient.TopicAdminClient.CreateTopic(c.context, &pubsubpb.Topic{
+			Name: c.topicName(topicID),
+		})
+		if err != nil && status.Code(err) != codes.AlreadyExists {
 			return fmt.Errorf("failed to create topic: %w", err)
 		}
 	}
@@ -85,8 +101,21 @@ func (c *client) CreateTopic(topicID string) error {
 	return nil
 }
 
+// SubscriptionOption configures a subscription created by CreateSubscription. Options
+// only apply when the subscription is created; existing subscriptions are left as is.
+type SubscriptionOption func(*pubsubpb.Subscription)
+
+// WithSubscriptionOrdering delivers messages sharing an ordering key in the order they
+// were published (see WithOrderingKey). Message ordering cannot be changed once the
+// subscription exists.
+func WithSubscriptionOrdering() SubscriptionOption {
+	return func(s *pubsubpb.Subscription) {
+		s.EnableMessageOrdering = true
+	}
+}
+
 // CreateSubscription creates a new subscription for a topic if it doesn't exist.
-func (c *client) CreateSubscription(topicID, subscriptionID string) error {
+func (c *client) CreateSubscription(topicID, subscriptionID string, opts ...SubscriptionOption) error {
 	if topicID == "" {
 		return fmt.Errorf("topic ID cannot be empty")
 	}
@@ -94,19 +123,22 @@ func (c *client) CreateSubscription(topicID, subscriptionID string) error {
 		return fmt.Errorf("subscription ID cannot be empty")
 	}
 
-	sub := c.pubsubClient.Subscription(subscriptionID)
-	exists, err := sub.Exists(c.context)
+	exists, err := c.subscriptionExists(subscriptionID)
 	if err != nil {
 		return fmt.Errorf("failed to check if subscription exists: %w", err)
 	}
 
 	if !exists {
-		topic := c.pubsubClient.Topic(topicID)
-		_, err = c.pubsubClient.CreateSubscription(c.context, subscriptionID, pubsub.SubscriptionConfig{
-			Topic:       topic,
-			AckDeadline: 60, // 60 seconds
-		})
-		if err != nil {
+		sub := &pubsubpb.Subscription{
+			Name:               c.subscriptionName(subscriptionID),
+			Topic:              c.topicName(topicID),
+			AckDeadlineSeconds: 60,
+		}
+		for _, opt := range opts {
+			opt(sub)
+		}
+		_, err = c.pubsubClient.SubscriptionAdminClient.CreateSubscription(c.context, sub)
+		if err != nil && status.Code(err) != codes.AlreadyExists {
 			return fmt.Errorf("failed to create subscription: %w", err)
 		}
 	}
@@ -114,6 +146,44 @@ func (c *client) CreateSubscription(topicID, subscriptionID string) error {
 	return nil
 }
 
+// topicExists reports whether the topic exists.
+func (c *client) topicExists(topicID string) (bool, error) {
+	_, err := c.pubsubClient.TopicAdminClient.GetTopic(c.context, &pubsubpb.GetTopicRequest{
+		Topic: c.topicName(topicID),
+	})
+	return found(err)
+}
+
+// subscriptionExists reports whether the subscription exists.
+func (c *client) subscriptionExists(subscriptionID string) (bool, error) {
+	_, err := c.pubsubClient.SubscriptionAdminClient.GetSubscription(c.context, &pubsubpb.GetSubscriptionRequest{
+		Subscription: c.subscriptionName(subscriptionID),
+	})
+	return found(err)
+}
+
+// found converts the error of a Get admin call into an existence check.
+func found(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case status.Code(err) == codes.NotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// topicName returns the fully qualified name of a topic of the client project.
+func (c *client) topicName(topicID string) string {
+	return fmt.Sprintf("projects/%s/topics/%s", c.projectID, topicID)
+}
+
+// subscriptionName returns the fully qualified name of a subscription of the client project.
+func (c *client) subscriptionName(subscriptionID string) string {
+	return fmt.Sprintf("projects/%s/subscriptions/%s", c.projectID, subscriptionID)
+}
+
 // Close closes the Pub/Sub client gracefully.
 func (c *client) Close() error {
 	if c.pubsubClient == nil {
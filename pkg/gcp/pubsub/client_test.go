@@ -0,0 +1,42 @@
+package pubsub
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+)
+
+func TestCreateSubscriptionOptions(t *testing.T) {
+	c, _ := newTestClient(t)
+	if err := c.CreateTopic("orders"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	for _, tc := range []struct {
+		subscription string
+		opts         []SubscriptionOption
+		wantOrdering bool
+	}{
+		{subscription: "orders-unordered"},
+		{subscription: "orders-ordered", opts: []SubscriptionOption{WithSubscriptionOrdering()}, wantOrdering: true},
+	} {
+		if err := c.CreateSubscription("orders", tc.subscription, tc.opts...); err != nil {
+			t.Fatalf("CreateSubscription(%s): %v", tc.subscription, err)
+		}
+		sub, err := c.pubsubClient.SubscriptionAdminClient.GetSubscription(t.Context(), &pubsubpb.GetSubscriptionRequest{
+			Subscription: c.subscriptionName(tc.subscription),
+		})
+		if err != nil {
+			t.Fatalf("GetSubscription(%s): %v", tc.subscription, err)
+		}
+		if sub.EnableMessageOrdering != tc.wantOrdering || sub.AckDeadlineSeconds != 60 {
+			t.Fatalf("%s: ordering %v, ack deadline %ds, want ordering %v and 60s",
+				tc.subscription, sub.EnableMessageOrdering, sub.AckDeadlineSeconds, tc.wantOrdering)
+		}
+	}
+
+	// Options only apply on creation: an existing subscription is left as is.
+	if err := c.CreateSubscription("orders", "orders-unordered", WithSubscriptionOrdering()); err != nil {
+		t.Fatalf("CreateSubscription of an existing subscription: %v", err)
+	}
+}
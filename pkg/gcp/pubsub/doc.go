@@ -2,6 +2,7 @@
 //
 // This package offers a simple interface for publishing and subscribing to messages
 // using Google Cloud Pub/Sub, supporting topics, subscriptions, and custom message handlers.
+// It is built on the cloud.google.com/go/pubsub/v2 client.
 //
 // Features:
 //   - Thread-safe publisher operations
@@ -10,6 +11,8 @@
 //   - Custom message attributes
 //   - Concurrent message processing
 //   - Panic recovery in handlers
+//   - Asynchronous batched publishing with flow control (PublishAsync)
+//   - Message ordering with ordering keys (WithOrderingKey, WithSubscriptionOrdering)
 //
 // Example Publisher:
 //
@@ -3,11 +3,12 @@ package pubsub
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
-	//nolint:staticcheck // v1 client kept for compatibility; upgrade to v2 pending.
-	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/v2"
 )
 
 // Publisher publishes messages to Google Cloud Pub/Sub topics.
@@ -16,30 +17,135 @@ type Publisher interface {
 	// Publish sends a message to the topic.
 	// The body will be automatically marshaled to JSON.
 	// Returns the message ID on success.
-	Publish(ctx context.Context, body any) (string, error)
+	Publish(ctx context.Context, body any, opts ...PublishOption) (string, error)
 	// PublishWithAttributes sends a message with custom attributes to the topic.
-	PublishWithAttributes(ctx context.Context, body any, attributes map[string]string) (string, error)
+	PublishWithAttributes(ctx context.Context, body any, attributes map[string]string, opts ...PublishOption) (string, error)
+	// PublishAsync queues a message with custom attributes for batched publishing and
+	// returns without waiting for the server. Use the returned result to get the
+	// message ID or the publish error.
+	PublishAsync(ctx context.Context, body any, attributes map[string]string, opts ...PublishOption) *PublishResult
+	// ResumePublish resumes publishing with an ordering key after a failure paused it.
+	ResumePublish(orderingKey string)
 	// Stop waits for all published messages to be acknowledged and stops the publisher.
 	Stop()
 }
 
+// ErrOrderingKeyPaused is returned for messages published with an ordering key whose
+// publishing is paused by an earlier failure. Call Publisher.ResumePublish once the
+// failure is handled.
+var ErrOrderingKeyPaused = errors.New("publishing paused for ordering key")
+
+// PublisherOption configures the batching, flow control and ordering of a Publisher
+// created by NewPublisher. Unset values keep the Pub/Sub client defaults: batches of
+// up to 100 messages or 1 MB, sent after at most 10ms.
+type PublisherOption func(*pubsub.Publisher)
+
+// WithBatchSize publishes a batch as soon as it holds n messages (at most 1000).
+func WithBatchSize(n int) PublisherOption {
+	return func(p *pubsub.Publisher) {
+		p.PublishSettings.CountThreshold = n
+	}
+}
+
+// WithBatchBytes publishes a batch as soon as its messages add up to n bytes.
+func WithBatchBytes(n int) PublisherOption {
+	return func(p *pubsub.Publisher) {
+		p.PublishSettings.ByteThreshold = n
+	}
+}
+
+// WithBatchDelay publishes a non-empty batch after d, even if it is not full. Higher
+// delays build larger batches at the cost of latency.
+func WithBatchDelay(d time.Duration) PublisherOption {
+	return func(p *pubsub.Publisher) {
+		p.PublishSettings.DelayThreshold = d
+	}
+}
+
+// WithFlowControl bounds the messages and bytes waiting to be published. Once either
+// limit is reached, PublishAsync blocks until earlier messages are sent or its context
+// is done, so producers faster than the network cannot exhaust memory. Zero or
+// negative values disable the corresponding limit.
+func WithFlowControl(maxMessages, maxBytes int) PublisherOption {
+	return func(p *pubsub.Publisher) {
+		p.PublishSettings.FlowControlSettings = pubsub.FlowControlSettings{
+			MaxOutstandingMessages: maxMessages,
+			MaxOutstandingBytes:    maxBytes,
+			LimitExceededBehavior:  pubsub.FlowControlBlock,
+		}
+	}
+}
+
+// WithMessageOrdering enables publishing messages with WithOrderingKey. Messages
+// sharing an ordering key are delivered in publish order to subscriptions created
+// with WithSubscriptionOrdering. When a message fails, later messages with its key
+// fail with ErrOrderingKeyPaused until ResumePublish is called.
+func WithMessageOrdering() PublisherOption {
+	return func(p *pubsub.Publisher) {
+		p.EnableMessageOrdering = true
+	}
+}
+
+// PublishOption customizes a single published message.
+type PublishOption func(*pubsub.Message)
+
+// WithOrderingKey sets the ordering key of the message. It requires a publisher
+// created with WithMessageOrdering.
+func WithOrderingKey(key string) PublishOption {
+	return func(msg *pubsub.Message) {
+		msg.OrderingKey = key
+	}
+}
+
+// PublishResult is the outcome of a message published with PublishAsync.
+type PublishResult struct {
+	result *pubsub.PublishResult
+	err    error
+}
+
+// Get blocks until the message is published or ctx is done, and returns the
+// server-assigned message ID.
+func (r *PublishResult) Get(ctx context.Context) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	messageID, err := r.result.Get(ctx)
+	var paused pubsub.ErrPublishingPaused
+	if errors.As(err, &paused) {
+		return "", fmt.Errorf("failed to publish message: %w %q", ErrOrderingKeyPaused, paused.OrderingKey)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to publish message: %w", err)
+	}
+	return messageID, nil
+}
+
+// Ready returns a channel that is closed once the outcome is known, so results can be
+// awaited in a select.
+func (r *PublishResult) Ready() <-chan struct{} {
+	if r.err != nil {
+		ready := make(chan struct{})
+		close(ready)
+		return ready
+	}
+	return r.result.Ready()
+}
+
 type publisher struct {
-	topic   *pubsub.Topic
+	topic   *pubsub.Publisher
 	mu      sync.Mutex
 	stopped bool
 }
 
 // NewPublisher creates a new publisher for publishing messages to a topic.
 // The publisher validates that the topic exists before creating.
-func (c *client) NewPublisher(topicID string) (Publisher, error) {
+func (c *client) NewPublisher(topicID string, opts ...PublisherOption) (Publisher, error) {
 	if topicID == "" {
 		return nil, fmt.Errorf("topic ID cannot be empty")
 	}
 
-	topic := c.pubsubClient.Topic(topicID)
-
 	// Check if topic exists
-	exists, err := topic.Exists(c.context)
+	exists, err := c.topicExists(topicID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check if topic exists: %w", err)
 	}
@@ -47,6 +153,11 @@ func (c *client) NewPublisher(topicID string) (Publisher, error) {
 		return nil, fmt.Errorf("topic %s does not exist", topicID)
 	}
 
+	topic := c.pubsubClient.Publisher(topicID)
+	for _, opt := range opts {
+		opt(topic)
+	}
+
 	return &publisher{
 		topic:   topic,
 		stopped: false,
@@ -56,42 +167,55 @@ func (c *client) NewPublisher(topicID string) (Publisher, error) {
 // Publish sends a message to the topic.
 // The message body is automatically marshaled to JSON.
 // Thread-safe: Multiple goroutines can safely call Publish concurrently.
-func (p *publisher) Publish(ctx context.Context, body any) (string, error) {
-	return p.PublishWithAttributes(ctx, body, nil)
+func (p *publisher) Publish(ctx context.Context, body any, opts ...PublishOption) (string, error) {
+	return p.PublishWithAttributes(ctx, body, nil, opts...)
 }
 
 // PublishWithAttributes sends a message with custom attributes to the topic.
 // The message body is automatically marshaled to JSON.
 // Thread-safe: Multiple goroutines can safely call Publish concurrently.
-func (p *publisher) PublishWithAttributes(ctx context.Context, body any, attributes map[string]string) (string, error) {
+func (p *publisher) PublishWithAttributes(ctx context.Context, body any, attributes map[string]string, opts ...PublishOption) (string, error) {
+	// Block until the message is published and get the server-assigned message ID
+	return p.PublishAsync(ctx, body, attributes, opts...).Get(ctx)
+}
+
+// PublishAsync queues a message with custom attributes for batched publishing.
+// The message body is automatically marshaled to JSON; marshaling and validation
+// errors are reported by the returned result.
+// Thread-safe: Multiple goroutines can safely call PublishAsync concurrently.
+func (p *publisher) PublishAsync(ctx context.Context, body any, attributes map[string]string, opts ...PublishOption) *PublishResult {
 	p.mu.Lock()
 	if p.stopped {
 		p.mu.Unlock()
-		return "", fmt.Errorf("publisher has been stopped")
+		return &PublishResult{err: fmt.Errorf("publisher has been stopped")}
 	}
 	p.mu.Unlock()
 
 	if body == nil {
-		return "", fmt.Errorf("message body cannot be nil")
+		return &PublishResult{err: fmt.Errorf("message body cannot be nil")}
 	}
 
 	bytes, err := json.Marshal(body)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal message body: %w", err)
+		return &PublishResult{err: fmt.Errorf("failed to marshal message body: %w", err)}
 	}
 
-	result := p.topic.Publish(ctx, &pubsub.Message{
+	msg := &pubsub.Message{
 		Data:       bytes,
 		Attributes: attributes,
-	})
-
-	// Block until the message is published and get the server-assigned message ID
-	messageID, err := result.Get(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to publish message: %w", err)
+	}
+	for _, opt := range opts {
+		opt(msg)
 	}
 
-	return messageID, nil
+	return &PublishResult{result: p.topic.Publish(ctx, msg)}
+}
+
+// ResumePublish resumes publishing with orderingKey after a failure paused it.
+// Messages published with the key in the meantime failed with ErrOrderingKeyPaused
+// and must be published again, in order.
+func (p *publisher) ResumePublish(orderingKey string) {
+	p.topic.ResumePublish(orderingKey)
 }
 
 // Stop waits for all published messages to be acknowledged and stops the publisher.
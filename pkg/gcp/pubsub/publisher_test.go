@@ -0,0 +1,164 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/v2/pstest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const testProject = "test-project"
+
+// newTestClient returns a client connected to an in-process Pub/Sub fake.
+func newTestClient(t *testing.T) (*client, *pstest.Server) {
+	t.Helper()
+	srv := pstest.NewServer()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	conn, err := grpc.NewClient(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	pubsubClient, err := pubsub.NewClient(t.Context(), testProject, option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("pubsub.NewClient: %v", err)
+	}
+	c := &client{
+		pubsubClient: pubsubClient,
+		projectID:    testProject,
+		context:      t.Context(),
+		logger:       slog.New(slog.DiscardHandler),
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c, srv
+}
+
+// newTestPublisher creates the topic and a publisher for it.
+func newTestPublisher(t *testing.T, c *client, topicID string, opts ...PublisherOption) *publisher {
+	t.Helper()
+	if err := c.CreateTopic(topicID); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	p, err := c.NewPublisher(topicID, opts...)
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	t.Cleanup(p.Stop)
+	return p.(*publisher)
+}
+
+func TestPublisherOptions(t *testing.T) {
+	c, _ := newTestClient(t)
+	p := newTestPublisher(t, c, "orders",
+		WithBatchSize(50),
+		WithBatchBytes(4096),
+		WithBatchDelay(250*time.Millisecond),
+		WithFlowControl(10, 1<<20),
+		WithMessageOrdering())
+
+	settings := p.topic.PublishSettings
+	if settings.CountThreshold != 50 || settings.ByteThreshold != 4096 || settings.DelayThreshold != 250*time.Millisecond {
+		t.Fatalf("batching = (%d messages, %d bytes, %s), want (50, 4096, 250ms)",
+			settings.CountThreshold, settings.ByteThreshold, settings.DelayThreshold)
+	}
+	want := pubsub.FlowControlSettings{
+		MaxOutstandingMessages: 10,
+		MaxOutstandingBytes:    1 << 20,
+		LimitExceededBehavior:  pubsub.FlowControlBlock,
+	}
+	if settings.FlowControlSettings != want {
+		t.Fatalf("flow control = %+v, want %+v", settings.FlowControlSettings, want)
+	}
+	if !p.topic.EnableMessageOrdering {
+		t.Fatal("message ordering not enabled")
+	}
+
+	defaults := newTestPublisher(t, c, "payments")
+	if defaults.topic.PublishSettings != pubsub.DefaultPublishSettings || defaults.topic.EnableMessageOrdering {
+		t.Fatalf("publisher without options = %+v, want the client defaults", defaults.topic.PublishSettings)
+	}
+}
+
+func TestPublishAsyncResolvesResult(t *testing.T) {
+	c, srv := newTestClient(t)
+	p := newTestPublisher(t, c, "orders")
+	srv.SetAutoPublishResponse(false)
+	srv.ResetPublishResponses(1)
+
+	result := p.PublishAsync(context.Background(), map[string]string{"id": "1"}, map[string]string{"source": "test"})
+	select {
+	case <-result.Ready():
+		t.Fatal("result ready before the server responded")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	srv.AddPublishResponse(&pubsubpb.PublishResponse{MessageIds: []string{"m42"}}, nil)
+	select {
+	case <-result.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("result not ready after the server responded")
+	}
+	if id, err := result.Get(context.Background()); err != nil || id != "m42" {
+		t.Fatalf("Get = (%q, %v), want m42", id, err)
+	}
+}
+
+func TestPublishAsyncReportsErrorsThroughResult(t *testing.T) {
+	c, _ := newTestClient(t)
+	p := newTestPublisher(t, c, "orders")
+
+	for name, result := range map[string]*PublishResult{
+		"nil body":      p.PublishAsync(context.Background(), nil, nil),
+		"invalid JSON":  p.PublishAsync(context.Background(), make(chan int), nil),
+		"after stopped": func() *PublishResult { p.Stop(); return p.PublishAsync(context.Background(), "x", nil) }(),
+	} {
+		select {
+		case <-result.Ready():
+		default:
+			t.Errorf("%s: result not ready", name)
+		}
+		if _, err := result.Get(context.Background()); err == nil {
+			t.Errorf("%s: Get succeeded", name)
+		}
+	}
+}
+
+func TestOrderingKeyPausedUntilResumed(t *testing.T) {
+	c, srv := newTestClient(t)
+	p := newTestPublisher(t, c, "orders", WithMessageOrdering())
+	ctx := context.Background()
+
+	srv.SetAutoPublishResponse(false)
+	srv.ResetPublishResponses(1)
+	srv.AddPublishResponse(nil, status.Error(codes.InvalidArgument, "rejected"))
+	if _, err := p.Publish(ctx, map[string]int{"seq": 1}, WithOrderingKey("customer-1")); err == nil || errors.Is(err, ErrOrderingKeyPaused) {
+		t.Fatalf("first ordered publish = %v, want the server error", err)
+	}
+	srv.SetAutoPublishResponse(true)
+
+	if _, err := p.Publish(ctx, map[string]int{"seq": 2}, WithOrderingKey("customer-1")); !errors.Is(err, ErrOrderingKeyPaused) {
+		t.Fatalf("publish after the failure = %v, want ErrOrderingKeyPaused", err)
+	}
+	if _, err := p.Publish(ctx, map[string]int{"seq": 1}, WithOrderingKey("customer-2")); err != nil {
+		t.Fatalf("publish with another key = %v, want it unaffected", err)
+	}
+
+	p.ResumePublish("customer-1")
+	id, err := p.Publish(ctx, map[string]int{"seq": 2}, WithOrderingKey("customer-1"))
+	if err != nil {
+		t.Fatalf("publish after ResumePublish = %v", err)
+	}
+	if msg := srv.Message(id); msg == nil || msg.OrderingKey != "customer-1" {
+		t.Fatalf("published message = %+v, want ordering key customer-1", msg)
+	}
+}
@@ -5,8 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 
-	//nolint:staticcheck // v1 client kept for compatibility; upgrade to v2 pending.
-	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/v2"
 )
 
 // Subscriber receives messages from Google Cloud Pub/Sub subscriptions.
@@ -17,15 +16,48 @@ type Subscriber interface {
 }
 
 type subscriber struct {
-	subscription *pubsub.Subscription
+	subscription *pubsub.Subscriber
 	handler      SubscriberHandler
 	context      context.Context
 	name         string
+	logger       *slog.Logger
+	logAttrs     []any
+	errorLevel   func(error) slog.Level
+}
+
+// SubscriberOption configures a Subscriber created by NewSubscriber.
+type SubscriberOption func(*subscriber)
+
+// WithSubscriberLogger overrides the client logger for a single subscriber.
+func WithSubscriberLogger(l *slog.Logger) SubscriberOption {
+	return func(s *subscriber) {
+		if l != nil {
+			s.logger = l
+		}
+	}
+}
+
+// WithLogAttrs attaches attributes to every log record emitted by the subscriber.
+// Arguments follow the slog.Logger.With convention (key-value pairs or slog.Attr).
+func WithLogAttrs(args ...any) SubscriberOption {
+	return func(s *subscriber) {
+		s.logAttrs = append(s.logAttrs, args...)
+	}
+}
+
+// WithErrorLogLevel sets the function that chooses the level used to log handler errors.
+// By default every handler error is logged at slog.LevelError.
+func WithErrorLogLevel(fn func(err error) slog.Level) SubscriberOption {
+	return func(s *subscriber) {
+		if fn != nil {
+			s.errorLevel = fn
+		}
+	}
 }
 
 // NewSubscriber creates a new subscriber for receiving messages from a subscription.
 // The subscription must exist before calling this method.
-func (c *client) NewSubscriber(subscriptionID string, handler SubscriberHandler) (Subscriber, error) {
+func (c *client) NewSubscriber(subscriptionID string, handler SubscriberHandler, opts ...SubscriberOption) (Subscriber, error) {
 	if subscriptionID == "" {
 		return nil, fmt.Errorf("subscription ID cannot be empty")
 	}
@@ -34,10 +66,8 @@ func (c *client) NewSubscriber(subscriptionID string, handler SubscriberHandler)
 		return nil, fmt.Errorf("handler cannot be nil")
 	}
 
-	sub := c.pubsubClient.Subscription(subscriptionID)
-
 	// Check if subscription exists
-	exists, err := sub.Exists(c.context)
+	exists, err := c.subscriptionExists(subscriptionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check if subscription exists: %w", err)
 	}
@@ -45,12 +75,20 @@ func (c *client) NewSubscriber(subscriptionID string, handler SubscriberHandler)
 		return nil, fmt.Errorf("subscription %s does not exist", subscriptionID)
 	}
 
-	return &subscriber{
-		subscription: sub,
+	s := &subscriber{
+		subscription: c.pubsubClient.Subscriber(subscriptionID),
 		handler:      handler,
 		context:      c.context,
 		name:         subscriptionID,
-	}, nil
+		logger:       c.logger,
+		errorLevel:   func(error) slog.Level { return slog.LevelError },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.logger = s.logger.With(slog.String("subscription", s.name)).With(s.logAttrs...)
+
+	return s, nil
 }
 
 // Receive starts receiving messages with the specified concurrency.
@@ -67,12 +105,11 @@ func (s *subscriber) Receive(concurrency int) error {
 		return fmt.Errorf("concurrency must be greater than 0")
 	}
 
-	// Configure subscription settings
+	// Limit the messages processed at once; NumGoroutines only sets the number of
+	// pull streams, so the default single stream is kept
 	s.subscription.ReceiveSettings.MaxOutstandingMessages = concurrency
-	s.subscription.ReceiveSettings.NumGoroutines = concurrency
 
-	slog.Info("subscriber started",
-		slog.String("subscription", s.name),
+	s.logger.Info("subscriber started",
 		slog.Int("concurrency", concurrency))
 
 	// Receive blocks until context is canceled
@@ -81,39 +118,35 @@ func (s *subscriber) Receive(concurrency int) error {
 	})
 
 	if err != nil {
-		slog.Error("subscriber error",
-			slog.String("error", err.Error()),
-			slog.String("subscription", s.name))
+		s.logger.Error("subscriber error",
+			slog.String("error", err.Error()))
 		return fmt.Errorf("subscription receive error: %w", err)
 	}
 
 	// Context was canceled - graceful shutdown
-	slog.Info("subscriber stopped gracefully", slog.String("subscription", s.name))
+	s.logger.Info("subscriber stopped gracefully")
 	return nil
 }
 
 func (s *subscriber) handleMessage(ctx context.Context, msg *pubsub.Message) {
 	defer func() {
 		if r := recover(); r != nil {
-			slog.Error("panic recovered in message handler",
+			s.logger.Error("panic recovered in message handler",
 				slog.Any("panic", r),
-				slog.String("subscription", s.name),
 				slog.String("messageID", msg.ID))
 			msg.Nack()
 		}
 	}()
 
 	if err := s.handler.HandleMessage(msg.Data, msg.Attributes); err != nil {
-		slog.Error("error handling message",
+		s.logger.Log(ctx, s.errorLevel(err), "error handling message",
 			slog.String("error", err.Error()),
-			slog.String("subscription", s.name),
 			slog.String("messageID", msg.ID))
 		msg.Nack()
 		return
 	}
 
-	slog.Debug("message handled successfully",
-		slog.String("subscription", s.name),
+	s.logger.Debug("message handled successfully",
 		slog.String("messageID", msg.ID))
 	msg.Ack()
 }
@@ -0,0 +1,174 @@
+package rabbitmq
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a consumer circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets messages flow normally.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen stops consumption after too many handler failures.
+	BreakerOpen
+	// BreakerHalfOpen consumes a single probe message to decide whether to close or reopen.
+	BreakerHalfOpen
+)
+
+// String returns the state name.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerCfg configures a consumer circuit breaker.
+//
+// While closed, handler results are counted in a fixed time window. When at least
+// MinRequests results were seen and the failure ratio reaches FailureRatio, the breaker
+// opens: the consumer cancels its AMQP subscription (basic.cancel), requeues buffered
+// deliveries and waits OpenTimeout. It then subscribes again with a prefetch of one
+// (half-open); if the probe message succeeds the breaker closes and normal consumption
+// resumes, otherwise it opens again. Handler results wrapping ErrDeadLetter and
+// messages that fail to decode are not counted.
+type CircuitBreakerCfg struct {
+	// FailureRatio is the handler error rate, between 0 and 1, that opens the breaker. Defaults to 0.5.
+	FailureRatio float64
+	// MinRequests is the minimum number of results in the window before the ratio is evaluated. Defaults to 10.
+	MinRequests int
+	// Window is the duration over which results are counted. Defaults to one minute.
+	Window time.Duration
+	// OpenTimeout is how long consumption stays stopped before a half-open probe. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// OnStateChange is called after every state transition. Optional.
+	OnStateChange func(consumerName string, from, to BreakerState)
+}
+
+type circuitBreaker struct {
+	cfg         CircuitBreakerCfg
+	name        string
+	onChange    func(from, to BreakerState)
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	total       int
+	failures    int
+	openedAt    time.Time
+}
+
+// newCircuitBreaker creates a closed breaker; onChange is called after every transition.
+func newCircuitBreaker(name string, cfg CircuitBreakerCfg, onChange func(from, to BreakerState)) *circuitBreaker {
+	if cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		cfg.FailureRatio = 0.5
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 10
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+
+	return &circuitBreaker{
+		cfg:         cfg,
+		name:        name,
+		onChange:    onChange,
+		windowStart: time.Now(),
+	}
+}
+
+// current returns the breaker state.
+func (b *circuitBreaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow reports whether messages may be consumed. While open it returns false and how
+// long to wait; once OpenTimeout has elapsed the breaker moves to half-open.
+func (b *circuitBreaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	if b.state != BreakerOpen {
+		b.mu.Unlock()
+		return true, 0
+	}
+	if wait := time.Until(b.openedAt.Add(b.cfg.OpenTimeout)); wait > 0 {
+		b.mu.Unlock()
+		return false, wait
+	}
+	b.state = BreakerHalfOpen
+	b.mu.Unlock()
+
+	b.transition(BreakerOpen, BreakerHalfOpen)
+	return true, 0
+}
+
+// record counts a handler result and applies the resulting transition, if any.
+// ErrDeadLetter results are ignored: the message was rejected, not the handler failing.
+func (b *circuitBreaker) record(err error) {
+	if errors.Is(err, ErrDeadLetter) {
+		return
+	}
+
+	b.mu.Lock()
+	from := b.state
+	to := from
+
+	switch from {
+	case BreakerHalfOpen:
+		if err != nil {
+			to = BreakerOpen
+		} else {
+			to = BreakerClosed
+		}
+	case BreakerClosed:
+		now := time.Now()
+		if now.Sub(b.windowStart) > b.cfg.Window {
+			b.windowStart, b.total, b.failures = now, 0, 0
+		}
+		b.total++
+		if err != nil {
+			b.failures++
+		}
+		if b.total >= b.cfg.MinRequests && float64(b.failures)/float64(b.total) >= b.cfg.FailureRatio {
+			to = BreakerOpen
+		}
+	case BreakerOpen:
+		// results of messages that were in flight when the breaker opened are ignored
+	}
+
+	if to != from {
+		b.state = to
+		b.windowStart, b.total, b.failures = time.Now(), 0, 0
+		if to == BreakerOpen {
+			b.openedAt = time.Now()
+		}
+	}
+	b.mu.Unlock()
+
+	if to != from {
+		b.transition(from, to)
+	}
+}
+
+// transition notifies the consumer and the user callback of a state change.
+func (b *circuitBreaker) transition(from, to BreakerState) {
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.name, from, to)
+	}
+}
@@ -0,0 +1,133 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var errHandler = errors.New("dependency down")
+
+type transitionLog []string
+
+func (l *transitionLog) record(from, to BreakerState) {
+	*l = append(*l, from.String()+"->"+to.String())
+}
+
+func TestCircuitBreakerOpensOnFailureRatio(t *testing.T) {
+	var transitions transitionLog
+	b := newCircuitBreaker("payments", CircuitBreakerCfg{FailureRatio: 0.5, MinRequests: 4}, transitions.record)
+
+	b.record(nil)
+	b.record(errHandler)
+	b.record(nil)
+	if b.current() != BreakerClosed {
+		t.Fatalf("state = %s before MinRequests, want closed", b.current())
+	}
+
+	b.record(errHandler)
+	if b.current() != BreakerOpen {
+		t.Fatalf("state = %s at a 50%% failure ratio, want open", b.current())
+	}
+	if ok, wait := b.allow(); ok || wait <= 0 {
+		t.Fatalf("allow() = %t, %s while open, want false and a wait", ok, wait)
+	}
+	if fmt.Sprint(transitions) != "[closed->open]" {
+		t.Fatalf("transitions = %v", transitions)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	for _, tc := range []struct {
+		probe error
+		want  BreakerState
+	}{
+		{probe: nil, want: BreakerClosed},
+		{probe: errHandler, want: BreakerOpen},
+	} {
+		var transitions transitionLog
+		b := newCircuitBreaker("payments", CircuitBreakerCfg{MinRequests: 1, OpenTimeout: time.Millisecond}, transitions.record)
+
+		b.record(errHandler)
+		time.Sleep(2 * time.Millisecond)
+		if ok, _ := b.allow(); !ok || b.current() != BreakerHalfOpen {
+			t.Fatalf("allow() after OpenTimeout = %t in state %s, want true in half-open", ok, b.current())
+		}
+
+		b.record(tc.probe)
+		if b.current() != tc.want {
+			t.Errorf("probe %v: state = %s, want %s", tc.probe, b.current(), tc.want)
+		}
+		if want := "[closed->open open->half-open half-open->" + tc.want.String() + "]"; fmt.Sprint(transitions) != want {
+			t.Errorf("probe %v: transitions = %v, want %s", tc.probe, transitions, want)
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresDeadLetters(t *testing.T) {
+	b := newCircuitBreaker("payments", CircuitBreakerCfg{MinRequests: 1, OpenTimeout: time.Millisecond}, nil)
+
+	b.record(fmt.Errorf("%w: poison message", ErrDeadLetter))
+	if b.current() != BreakerClosed {
+		t.Fatalf("state = %s after a dead-lettered message, want closed", b.current())
+	}
+
+	b.record(errHandler)
+	time.Sleep(2 * time.Millisecond)
+	b.allow()
+	b.record(ErrDeadLetter)
+	if b.current() != BreakerHalfOpen {
+		t.Fatalf("state = %s after a dead-lettered probe, want half-open", b.current())
+	}
+}
+
+func TestCircuitBreakerResetsWindow(t *testing.T) {
+	b := newCircuitBreaker("payments", CircuitBreakerCfg{MinRequests: 2, Window: time.Millisecond}, nil)
+
+	b.record(errHandler)
+	time.Sleep(2 * time.Millisecond)
+	b.record(errHandler)
+	if b.current() != BreakerClosed {
+		t.Fatalf("state = %s with failures in separate windows, want closed", b.current())
+	}
+}
+
+func TestConsumerRecordsResultBeforeSettling(t *testing.T) {
+	handler := testHandler(func([]byte) error { return errHandler })
+	c := newTestClient().newConsumer("payments", "payments", handler, []ConsumerOption{
+		WithCircuitBreaker(CircuitBreakerCfg{MinRequests: 1}),
+	})
+
+	var stateAtSettle BreakerState
+	c.processDelivery(&amqp091.Delivery{Body: []byte("{}")}, func(context.Context, error) { stateAtSettle = c.breaker.current() })
+	if stateAtSettle != BreakerOpen {
+		t.Fatalf("breaker state at settle = %s, want open", stateAtSettle)
+	}
+}
+
+// failingRegistry fails every validation as if the registry were unreachable.
+type failingRegistry struct{}
+
+func (failingRegistry) Validate(context.Context, string, string, []byte) error {
+	return errHandler
+}
+
+func TestConsumerDoesNotRecordDecodeFailures(t *testing.T) {
+	handler := testHandler(func([]byte) error { return nil })
+	c := newTestClient(WithSchemaValidation(SchemaCfg{Registry: failingRegistry{}})).newConsumer("payments", "payments", handler, []ConsumerOption{
+		WithCircuitBreaker(CircuitBreakerCfg{MinRequests: 1}),
+	})
+
+	var settled error
+	c.processDelivery(&amqp091.Delivery{Type: "payment.created", Body: []byte("{}")}, func(_ context.Context, err error) { settled = err })
+	if !errors.Is(settled, errHandler) {
+		t.Fatalf("settled error = %v, want the registry failure", settled)
+	}
+	if c.breaker.current() != BreakerClosed || c.breaker.total != 0 {
+		t.Fatalf("decode failure recorded by the breaker (state %s, total %d)", c.breaker.current(), c.breaker.total)
+	}
+}
@@ -0,0 +1,198 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ErrClaimNotFound is returned by a ClaimStore for keys it does not hold.
+var ErrClaimNotFound = errors.New("claim not found")
+
+const (
+	// headerClaimCheck carries the ClaimStore key of an offloaded payload.
+	headerClaimCheck = "x-claim-check"
+
+	defaultClaimThreshold = 1 << 20
+	defaultClaimPrefix    = "rabbitmq/"
+)
+
+// ClaimStore stores the payloads offloaded by WithClaimCheck. See the rabbitmqgcs
+// package for a Google Cloud Storage implementation.
+type ClaimStore interface {
+	// Put stores data under key.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the data stored under key, or an error wrapping ErrClaimNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the data stored under key.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryClaimStore keeps payloads in memory. It is useful in tests.
+type MemoryClaimStore struct {
+	mu       sync.Mutex
+	payloads map[string][]byte
+}
+
+var _ ClaimStore = (*MemoryClaimStore)(nil)
+
+// NewMemoryClaimStore creates an empty in-memory claim store.
+func NewMemoryClaimStore() *MemoryClaimStore {
+	return &MemoryClaimStore{payloads: make(map[string][]byte)}
+}
+
+// Put stores a copy of data.
+func (s *MemoryClaimStore) Put(_ context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads[key] = slices.Clone(data)
+	return nil
+}
+
+// Get returns the stored data.
+func (s *MemoryClaimStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.payloads[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClaimNotFound, key)
+	}
+	return slices.Clone(data), nil
+}
+
+// Delete removes the stored data.
+func (s *MemoryClaimStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.payloads, key)
+	return nil
+}
+
+// Len returns the number of stored payloads.
+func (s *MemoryClaimStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.payloads)
+}
+
+// ClaimCheckCfg configures the claim-check pattern: payloads above Threshold are
+// stored in Store and the message only carries a reference to them.
+type ClaimCheckCfg struct {
+	// Store holds the offloaded payloads. Required.
+	Store ClaimStore
+	// Threshold is the body size, in bytes, from which payloads are offloaded.
+	// It applies after compression and encryption. Defaults to 1 MiB.
+	Threshold int
+	// Prefix is prepended to the keys of offloaded payloads, which are named
+	// <Prefix><queue>/<unique id>. Defaults to "rabbitmq/".
+	Prefix string
+	// DeleteAfterAck deletes the offloaded payload once a consumer acknowledges the
+	// message. Payloads of nacked, dead-lettered and stream messages are kept, so they
+	// can be processed again; leave it disabled when several queues receive the same
+	// payload, and rely on a bucket lifecycle rule instead.
+	DeleteAfterAck bool
+}
+
+// claimReference is the body published in place of an offloaded payload, so consumers
+// that do not know the claim-check header fail to decode it instead of handling an
+// empty message.
+type claimReference struct {
+	ClaimCheck string `json:"claimCheck"`
+}
+
+type claimCheck struct {
+	cfg ClaimCheckCfg
+}
+
+func newClaimCheck(cfg ClaimCheckCfg) *claimCheck {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = defaultClaimThreshold
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = defaultClaimPrefix
+	}
+	return &claimCheck{cfg: cfg}
+}
+
+// offload stores the body of msg and replaces it with a reference when it reaches
+// the threshold.
+func (c *claimCheck) offload(ctx context.Context, queueName string, msg *amqp091.Publishing) error {
+	if c == nil || c.cfg.Store == nil || len(msg.Body) < c.cfg.Threshold {
+		return nil
+	}
+
+	key := c.cfg.Prefix + queueName + "/" + newMessageID()
+	if err := c.cfg.Store.Put(ctx, key, msg.Body); err != nil {
+		return fmt.Errorf("failed to store claim-check payload: %w", err)
+	}
+
+	reference, err := json.Marshal(claimReference{ClaimCheck: key})
+	if err != nil {
+		return fmt.Errorf("failed to marshal claim-check reference: %w", err)
+	}
+	if msg.Headers == nil {
+		msg.Headers = amqp091.Table{}
+	}
+	msg.Headers[headerClaimCheck] = key
+	msg.Body = reference
+
+	return nil
+}
+
+// fetch replaces the body of a delivery carrying a claim-check reference with the
+// stored payload. Payloads that are gone return an error wrapping ErrDeadLetter; other
+// store failures are returned as is, so the message is requeued.
+func (c *claimCheck) fetch(ctx context.Context, msg *amqp091.Delivery) error {
+	key, ok := msg.Headers[headerClaimCheck].(string)
+	if !ok {
+		return nil
+	}
+	if c == nil || c.cfg.Store == nil {
+		return fmt.Errorf("%w: message payload offloaded to %q but no claim store is configured", ErrDeadLetter, key)
+	}
+
+	body, err := c.cfg.Store.Get(ctx, key)
+	if errors.Is(err, ErrClaimNotFound) {
+		return fmt.Errorf("%w: %w", ErrDeadLetter, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch claim-check payload %q: %w", key, err)
+	}
+	msg.Body = body
+
+	return nil
+}
+
+// release deletes the payload of an acknowledged delivery when DeleteAfterAck is set.
+// Failures are only logged, as the message is already settled.
+func (c *claimCheck) release(ctx context.Context, logger *slog.Logger, headers amqp091.Table) {
+	key, ok := headers[headerClaimCheck].(string)
+	if !ok || c == nil || c.cfg.Store == nil || !c.cfg.DeleteAfterAck {
+		return
+	}
+	if err := c.cfg.Store.Delete(ctx, key); err != nil {
+		logger.Warn("failed to delete claim-check payload",
+			slog.String("key", key),
+			slog.String("error", err.Error()))
+	}
+}
+
+// discard deletes the payload offloaded for a message that failed to be published.
+// Failures are only logged, so the publish error is returned as is.
+func (c *claimCheck) discard(ctx context.Context, logger *slog.Logger, headers amqp091.Table) {
+	key, ok := headers[headerClaimCheck].(string)
+	if !ok || c == nil || c.cfg.Store == nil {
+		return
+	}
+	if err := c.cfg.Store.Delete(ctx, key); err != nil {
+		logger.Warn("failed to delete claim-check payload of unpublished message",
+			slog.String("key", key),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,120 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestClaimCheckRoundTrip(t *testing.T) {
+	store := NewMemoryClaimStore()
+	claims := newClaimCheck(ClaimCheckCfg{Store: store, Threshold: 8})
+	payload := []byte(`{"payload":"large enough"}`)
+
+	msg := amqp091.Publishing{Body: payload}
+	if err := claims.offload(context.Background(), "orders", &msg); err != nil {
+		t.Fatalf("offload: %v", err)
+	}
+	key, ok := msg.Headers[headerClaimCheck].(string)
+	if !ok || !strings.HasPrefix(key, defaultClaimPrefix+"orders/") {
+		t.Fatalf("claim key = %v, want prefix %q", msg.Headers[headerClaimCheck], defaultClaimPrefix+"orders/")
+	}
+	if bytes.Equal(msg.Body, payload) {
+		t.Fatal("body not replaced with a reference")
+	}
+	if store.Len() != 1 {
+		t.Fatalf("store holds %d payloads, want 1", store.Len())
+	}
+
+	delivery := amqp091.Delivery{Headers: msg.Headers, Body: msg.Body}
+	if err := claims.fetch(context.Background(), &delivery); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if !bytes.Equal(delivery.Body, payload) {
+		t.Fatalf("fetched body = %q, want %q", delivery.Body, payload)
+	}
+}
+
+func TestClaimCheckKeepsSmallBodies(t *testing.T) {
+	store := NewMemoryClaimStore()
+	claims := newClaimCheck(ClaimCheckCfg{Store: store, Threshold: 1024})
+
+	msg := amqp091.Publishing{Body: []byte(`{}`)}
+	if err := claims.offload(context.Background(), "orders", &msg); err != nil {
+		t.Fatalf("offload: %v", err)
+	}
+	if msg.Headers != nil || store.Len() != 0 {
+		t.Fatal("small body offloaded")
+	}
+}
+
+func TestClaimCheckMissingPayloadIsDeadLettered(t *testing.T) {
+	claims := newClaimCheck(ClaimCheckCfg{Store: NewMemoryClaimStore()})
+
+	delivery := amqp091.Delivery{Headers: amqp091.Table{headerClaimCheck: "rabbitmq/orders/gone"}}
+	err := claims.fetch(context.Background(), &delivery)
+	if !errors.Is(err, ErrDeadLetter) || !errors.Is(err, ErrClaimNotFound) {
+		t.Fatalf("fetch error = %v, want ErrDeadLetter and ErrClaimNotFound", err)
+	}
+
+	var unconfigured *claimCheck
+	if err := unconfigured.fetch(context.Background(), &delivery); !errors.Is(err, ErrDeadLetter) {
+		t.Fatalf("fetch without store error = %v, want ErrDeadLetter", err)
+	}
+}
+
+func TestClaimCheckReleaseHonorsDeleteAfterAck(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	headers := amqp091.Table{headerClaimCheck: "rabbitmq/orders/1"}
+
+	for _, tc := range []struct {
+		deleteAfterAck bool
+		want           int
+	}{
+		{deleteAfterAck: false, want: 1},
+		{deleteAfterAck: true, want: 0},
+	} {
+		store := NewMemoryClaimStore()
+		_ = store.Put(context.Background(), "rabbitmq/orders/1", []byte("payload"))
+
+		newClaimCheck(ClaimCheckCfg{Store: store, DeleteAfterAck: tc.deleteAfterAck}).release(context.Background(), logger, headers)
+
+		if store.Len() != tc.want {
+			t.Errorf("DeleteAfterAck=%t: store holds %d payloads, want %d", tc.deleteAfterAck, store.Len(), tc.want)
+		}
+	}
+}
+
+func TestProducerDiscardsClaimWhenPublishFails(t *testing.T) {
+	store := NewMemoryClaimStore()
+	p := newTestClient(WithClaimCheck(ClaimCheckCfg{Store: store, Threshold: 8})).newProducer()
+
+	// Without a connection the channel cannot be reopened, so the publish fails after
+	// the payload is offloaded
+	err := p.PublishWithContext(context.Background(), "orders", map[string]string{"payload": "large enough"})
+	if err == nil {
+		t.Fatal("publish without a connection succeeded")
+	}
+	if store.Len() != 0 {
+		t.Fatalf("store holds %d payloads after a failed publish, want 0", store.Len())
+	}
+}
+
+func TestProducerRejectsDelayedExchangeMessagesBeforeOffload(t *testing.T) {
+	store := NewMemoryClaimStore()
+	p := newTestClient(WithClaimCheck(ClaimCheckCfg{Store: store, Threshold: 8})).newProducer()
+
+	err := p.PublishToExchange(context.Background(), "events", "key", map[string]string{"payload": "large enough"}, WithDelay(time.Minute))
+	if err == nil {
+		t.Fatal("delayed publish to an exchange succeeded")
+	}
+	if store.Len() != 0 {
+		t.Fatalf("store holds %d payloads, want 0", store.Len())
+	}
+}
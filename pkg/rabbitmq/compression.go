@@ -0,0 +1,138 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// CompressionAlgorithm is a payload compression algorithm. Its value is written to the
+// AMQP content-encoding property of compressed messages.
+type CompressionAlgorithm string
+
+const (
+	// CompressionGzip compresses payloads with gzip.
+	CompressionGzip CompressionAlgorithm = "gzip"
+	// CompressionZstd compresses payloads with Zstandard: faster than gzip at a similar ratio.
+	CompressionZstd CompressionAlgorithm = "zstd"
+)
+
+// maxDecompressedSize bounds decompressed payloads so a malicious or corrupt message
+// cannot exhaust the consumer memory.
+const maxDecompressedSize = 512 << 20
+
+// compression compresses published payloads of at least threshold bytes.
+type compression struct {
+	algorithm CompressionAlgorithm
+	threshold int
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+	// zstd encoders and decoders are safe for concurrent EncodeAll/DecodeAll calls
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(nil)
+	})
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+		return zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	})
+)
+
+// apply compresses the body of msg when it is large enough, has no content encoding
+// yet and compression makes it smaller.
+func (c *compression) apply(msg *amqp091.Publishing) error {
+	if c == nil || msg.ContentEncoding != "" || len(msg.Body) < c.threshold {
+		return nil
+	}
+
+	compressed, err := compress(c.algorithm, msg.Body)
+	if err != nil {
+		return fmt.Errorf("failed to compress message body: %w", err)
+	}
+	if len(compressed) < len(msg.Body) {
+		msg.Body = compressed
+		msg.ContentEncoding = string(c.algorithm)
+	}
+	return nil
+}
+
+func compress(algorithm CompressionAlgorithm, body []byte) ([]byte, error) {
+	switch algorithm {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w, _ := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(w)
+		w.Reset(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case CompressionZstd:
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return enc.EncodeAll(body, make([]byte, 0, len(body)/2)), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %q", algorithm)
+	}
+}
+
+// decompressDelivery replaces the body of a gzip or zstd encoded delivery with the
+// decompressed payload and clears its content encoding. Other encodings are left
+// untouched, so uncompressed messages pass through. Payloads that cannot be
+// decompressed return an error wrapping ErrDeadLetter.
+func decompressDelivery(msg *amqp091.Delivery) error {
+	algorithm := CompressionAlgorithm(msg.ContentEncoding)
+	if algorithm != CompressionGzip && algorithm != CompressionZstd {
+		return nil
+	}
+
+	body, err := decompress(algorithm, msg.Body)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decompress %s message body: %w", ErrDeadLetter, algorithm, err)
+	}
+	msg.Body = body
+	msg.ContentEncoding = ""
+	return nil
+}
+
+func decompress(algorithm CompressionAlgorithm, body []byte) ([]byte, error) {
+	switch algorithm {
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = r.Close() }()
+		out, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(out) > maxDecompressedSize {
+			return nil, fmt.Errorf("decompressed body exceeds %d bytes", maxDecompressedSize)
+		}
+		return out, nil
+
+	case CompressionZstd:
+		dec, err := zstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		return dec.DecodeAll(body, nil)
+
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %q", algorithm)
+	}
+}
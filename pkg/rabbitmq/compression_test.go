@@ -0,0 +1,74 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"order":"compressible"}`, 100))
+
+	for _, algorithm := range []CompressionAlgorithm{CompressionGzip, CompressionZstd} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			msg := amqp091.Publishing{Body: payload}
+			if err := (&compression{algorithm: algorithm, threshold: 16}).apply(&msg); err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if msg.ContentEncoding != string(algorithm) {
+				t.Fatalf("content encoding = %q, want %q", msg.ContentEncoding, algorithm)
+			}
+			if len(msg.Body) >= len(payload) {
+				t.Fatalf("compressed body has %d bytes, want less than %d", len(msg.Body), len(payload))
+			}
+
+			delivery := amqp091.Delivery{ContentEncoding: msg.ContentEncoding, Body: msg.Body}
+			if err := decompressDelivery(&delivery); err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !bytes.Equal(delivery.Body, payload) || delivery.ContentEncoding != "" {
+				t.Fatalf("decompressed body does not match the payload")
+			}
+		})
+	}
+}
+
+func TestCompressionSkipsSmallAndIncompressibleBodies(t *testing.T) {
+	c := &compression{algorithm: CompressionGzip, threshold: 16}
+
+	small := amqp091.Publishing{Body: []byte(`{}`)}
+	if err := c.apply(&small); err != nil || small.ContentEncoding != "" {
+		t.Fatalf("small body compressed (err %v)", err)
+	}
+
+	// gzip adds a header, so a body of distinct bytes grows
+	incompressible := amqp091.Publishing{Body: []byte("abcdefghijklmnopqrstuvwxyz")}
+	if err := c.apply(&incompressible); err != nil || incompressible.ContentEncoding != "" {
+		t.Fatalf("incompressible body compressed (err %v)", err)
+	}
+}
+
+func TestDecompressCorruptBodyIsDeadLettered(t *testing.T) {
+	delivery := amqp091.Delivery{ContentEncoding: string(CompressionGzip), Body: []byte("not gzip")}
+	if err := decompressDelivery(&delivery); !errors.Is(err, ErrDeadLetter) {
+		t.Fatalf("decompress error = %v, want ErrDeadLetter", err)
+	}
+
+	plain := amqp091.Delivery{ContentEncoding: "identity", Body: []byte("{}")}
+	if err := decompressDelivery(&plain); err != nil {
+		t.Fatalf("decompress of an unknown encoding: %v", err)
+	}
+}
+
+func TestWithCompressionRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := newClient(context.Background(), []ClientOption{WithCompression("brotli", 0)}); err == nil {
+		t.Fatal("client created with an unknown compression algorithm")
+	}
+	if _, err := NewClient(context.Background(), "amqp://localhost:1/", WithCompression("brotli", 0)); err == nil || !strings.Contains(err.Error(), "brotli") {
+		t.Fatalf("NewClient error = %v, want the unsupported algorithm", err)
+	}
+}
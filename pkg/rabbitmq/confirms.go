@@ -0,0 +1,192 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// confirmBufferSize buffers publisher confirmations so the broker is not slowed down
+// while a batch of them is settled.
+const confirmBufferSize = 64
+
+// ErrProducerClosed is returned when publishing through a producer after Close or Shutdown.
+var ErrProducerClosed = errors.New("producer is closed")
+
+// UnconfirmedError is returned by Producer.Shutdown when the broker did not confirm
+// every published message before the context was done, or rejected some of them.
+type UnconfirmedError struct {
+	// MessageIDs are the IDs of the messages still waiting for a confirmation.
+	MessageIDs []string
+	// Nacked are the IDs of the messages the broker rejected (basic.nack) since the
+	// producer was created, in the order the rejections arrived.
+	Nacked []string
+	// Err is the context error that ended the wait, or nil when every message was
+	// confirmed but some were rejected.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *UnconfirmedError) Error() string {
+	msg := fmt.Sprintf("%d messages not confirmed by the broker", len(e.MessageIDs))
+	if len(e.Nacked) > 0 {
+		msg = fmt.Sprintf("%d messages rejected and %s", len(e.Nacked), msg)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *UnconfirmedError) Unwrap() error {
+	return e.Err
+}
+
+// confirmTracker tracks the messages published on a channel in confirm mode until the
+// broker confirms them. Delivery tags are per channel, so each channel has its own tracker.
+type confirmTracker struct {
+	mu      sync.Mutex
+	pending map[uint64]string
+	changed chan struct{}
+}
+
+func newConfirmTracker() *confirmTracker {
+	return &confirmTracker{pending: make(map[uint64]string), changed: make(chan struct{})}
+}
+
+// add records a message about to be published with tag.
+func (t *confirmTracker) add(tag uint64, messageID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[tag] = messageID
+}
+
+// remove forgets a message whose publish failed.
+func (t *confirmTracker) remove(tag uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, tag)
+	t.broadcast()
+}
+
+// confirm settles a confirmation and returns the ID of the confirmed message.
+func (t *confirmTracker) confirm(tag uint64) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	messageID := t.pending[tag]
+	delete(t.pending, tag)
+	t.broadcast()
+	return messageID
+}
+
+// abandon forgets every pending message, whose confirmation will never arrive because
+// the channel closed, and returns their IDs.
+func (t *confirmTracker) abandon() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ids := t.ids()
+	clear(t.pending)
+	t.broadcast()
+	return ids
+}
+
+// wait blocks until every pending message is confirmed or ctx is done, in which case
+// it returns an *UnconfirmedError.
+func (t *confirmTracker) wait(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		empty, changed := len(t.pending) == 0, t.changed
+		t.mu.Unlock()
+		if empty {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return &UnconfirmedError{MessageIDs: t.ids(), Err: ctx.Err()}
+		}
+	}
+}
+
+// ids returns the pending message IDs in publish order. Must be called with t.mu held.
+func (t *confirmTracker) ids() []string {
+	tags := slices.Sorted(maps.Keys(t.pending))
+	ids := make([]string, len(tags))
+	for i, tag := range tags {
+		ids[i] = t.pending[tag]
+	}
+	return ids
+}
+
+// broadcast wakes up waiters. Must be called with t.mu held.
+func (t *confirmTracker) broadcast() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// nackLog collects the IDs of the messages the broker rejected, across the channels
+// a producer opens, until Shutdown reports them.
+type nackLog struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+// add records a rejected message.
+func (l *nackLog) add(messageID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ids = append(l.ids, messageID)
+}
+
+// report adds the rejected messages to the result of waiting for confirmations and
+// forgets them, so each rejection is reported once.
+func (l *nackLog) report(waitErr error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.ids) == 0 {
+		return waitErr
+	}
+
+	var unconfirmed *UnconfirmedError
+	if !errors.As(waitErr, &unconfirmed) {
+		unconfirmed = &UnconfirmedError{}
+	}
+	unconfirmed.Nacked = l.ids
+	l.ids = nil
+	return unconfirmed
+}
+
+// watchChannel settles the confirmations of a producer channel until it closes, then
+// reports the messages left unconfirmed and the close error.
+func (p *producer) watchChannel(tracker *confirmTracker, confirms <-chan amqp091.Confirmation, closeChan <-chan *amqp091.Error) {
+	for c := range confirms {
+		messageID := tracker.confirm(c.DeliveryTag)
+		if !c.Ack {
+			p.nacks.add(messageID)
+			p.logger.Error("broker rejected published message", slog.String("messageId", messageID))
+		}
+	}
+
+	if lost := tracker.abandon(); len(lost) > 0 {
+		p.logger.Error("producer channel closed before the broker confirmed messages",
+			slog.Int("count", len(lost)),
+			slog.Any("messageIds", lost))
+	}
+
+	if err := <-closeChan; err != nil {
+		p.logger.Warn("producer channel closed", slog.String("error", err.Error()))
+		if p.onChannelClose != nil {
+			p.onChannelClose(err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestConfirmTrackerWaitsForConfirmations(t *testing.T) {
+	tracker := newConfirmTracker()
+	tracker.add(1, "a")
+	tracker.add(2, "b")
+
+	waited := make(chan error, 1)
+	go func() { waited <- tracker.wait(context.Background()) }()
+
+	tracker.confirm(2)
+	if id := tracker.confirm(1); id != "a" {
+		t.Fatalf("confirm(1) = %q, want a", id)
+	}
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait still blocked after every message was confirmed")
+	}
+}
+
+func TestConfirmTrackerReportsUnconfirmedInPublishOrder(t *testing.T) {
+	tracker := newConfirmTracker()
+	for tag, id := range map[uint64]string{3: "c", 1: "a", 2: "b", 4: "d"} {
+		tracker.add(tag, id)
+	}
+	tracker.remove(4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var unconfirmed *UnconfirmedError
+	if err := tracker.wait(ctx); !errors.As(err, &unconfirmed) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait error = %v, want an UnconfirmedError wrapping the deadline", err)
+	}
+	if !slices.Equal(unconfirmed.MessageIDs, []string{"a", "b", "c"}) {
+		t.Fatalf("unconfirmed = %v, want [a b c]", unconfirmed.MessageIDs)
+	}
+
+	if lost := tracker.abandon(); !slices.Equal(lost, []string{"a", "b", "c"}) {
+		t.Fatalf("abandon = %v, want [a b c]", lost)
+	}
+	if err := tracker.wait(context.Background()); err != nil {
+		t.Fatalf("wait after abandon: %v", err)
+	}
+}
+
+func TestShutdownReportsNackedMessages(t *testing.T) {
+	p := newTestClient().newProducer()
+	tracker := newConfirmTracker()
+	p.confirms = tracker
+	tracker.add(1, "accepted")
+	tracker.add(2, "rejected")
+
+	confirms := make(chan amqp091.Confirmation, 2)
+	confirms <- amqp091.Confirmation{DeliveryTag: 1, Ack: true}
+	confirms <- amqp091.Confirmation{DeliveryTag: 2, Ack: false}
+	close(confirms)
+	closeChan := make(chan *amqp091.Error)
+	close(closeChan)
+	p.watchChannel(tracker, confirms, closeChan)
+
+	err := p.Shutdown(context.Background())
+	var unconfirmed *UnconfirmedError
+	if !errors.As(err, &unconfirmed) {
+		t.Fatalf("Shutdown error = %v, want an UnconfirmedError", err)
+	}
+	if !slices.Equal(unconfirmed.Nacked, []string{"rejected"}) || len(unconfirmed.MessageIDs) != 0 || unconfirmed.Err != nil {
+		t.Fatalf("UnconfirmedError = %+v, want only the rejected message", unconfirmed)
+	}
+	if got, want := err.Error(), "1 messages rejected and 0 messages not confirmed by the broker"; got != want {
+		t.Fatalf("error = %q, want %q", got, want)
+	}
+}
+
+func TestShutdownWithoutPendingMessages(t *testing.T) {
+	p := newTestClient().newProducer()
+	p.confirms = newConfirmTracker()
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := p.Publish("orders", map[string]string{}); !errors.Is(err, ErrProducerClosed) {
+		t.Fatalf("Publish after Shutdown = %v, want ErrProducerClosed", err)
+	}
+}
+
+func TestNackLogReportsEachRejectionOnce(t *testing.T) {
+	var nacks nackLog
+	nacks.add("a")
+	nacks.add("b")
+
+	var unconfirmed *UnconfirmedError
+	if err := nacks.report(nil); !errors.As(err, &unconfirmed) || !slices.Equal(unconfirmed.Nacked, []string{"a", "b"}) {
+		t.Fatalf("report = %v, want a and b rejected", err)
+	}
+	if err := nacks.report(nil); err != nil {
+		t.Fatalf("second report = %v, want nil", err)
+	}
+
+	nacks.add("c")
+	waitErr := &UnconfirmedError{MessageIDs: []string{"d"}, Err: context.DeadlineExceeded}
+	if err := nacks.report(waitErr); !errors.As(err, &unconfirmed) || !slices.Equal(unconfirmed.Nacked, []string{"c"}) || !slices.Equal(unconfirmed.MessageIDs, []string{"d"}) {
+		t.Fatalf("report = %+v, want only c rejected and d unconfirmed", err)
+	}
+	if len(nacks.ids) != 0 {
+		t.Fatalf("nack log keeps %v after reporting", nacks.ids)
+	}
+}
+
+func TestShutdownDoesNotReportNacksTwice(t *testing.T) {
+	p := newTestClient().newProducer()
+	p.confirms = newConfirmTracker()
+	p.nacks.add("rejected")
+
+	if err := p.Shutdown(context.Background()); err == nil {
+		t.Fatal("first Shutdown did not report the rejected message")
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown = %v, want nil", err)
+	}
+}
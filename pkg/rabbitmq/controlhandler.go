@@ -0,0 +1,69 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// ConsumerStatus describes a consumer exposed by the control handler.
+type ConsumerStatus struct {
+	Name   string `json:"name"`
+	Paused bool   `json:"paused"`
+}
+
+// NewControlHandler returns an http.Handler that lets operators pause and resume
+// consumers, e.g. during maintenance windows or deployments. Consumers are addressed
+// by the keys of the consumers map. Routes:
+//
+//	GET  /consumers               list consumers and their paused state
+//	GET  /consumers/{name}        show one consumer
+//	POST /consumers/{name}/pause  pause a consumer
+//	POST /consumers/{name}/resume resume a consumer
+//
+// Mount it under a prefix with http.StripPrefix and protect it like any other admin endpoint.
+func NewControlHandler(consumers map[string]Consumer) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /consumers", func(w http.ResponseWriter, _ *http.Request) {
+		names := make([]string, 0, len(consumers))
+		for name := range consumers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		statuses := make([]ConsumerStatus, 0, len(names))
+		for _, name := range names {
+			statuses = append(statuses, ConsumerStatus{Name: name, Paused: consumers[name].IsPaused()})
+		}
+		writeJSON(w, http.StatusOK, statuses)
+	})
+
+	mux.HandleFunc("GET /consumers/{name}", withConsumer(consumers, func(Consumer) {}))
+	mux.HandleFunc("POST /consumers/{name}/pause", withConsumer(consumers, Consumer.Pause))
+	mux.HandleFunc("POST /consumers/{name}/resume", withConsumer(consumers, Consumer.Resume))
+
+	return mux
+}
+
+// withConsumer resolves the {name} path value, applies action to the consumer and
+// responds with its resulting status.
+func withConsumer(consumers map[string]Consumer, action func(Consumer)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		c, ok := consumers[name]
+		if !ok {
+			http.Error(w, "consumer not found", http.StatusNotFound)
+			return
+		}
+		action(c)
+		writeJSON(w, http.StatusOK, ConsumerStatus{Name: name, Paused: c.IsPaused()})
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
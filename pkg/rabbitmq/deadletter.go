@@ -0,0 +1,332 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetter is a message read from a dead-letter queue, with the details RabbitMQ
+// recorded in the x-death and x-first-death-* headers when it was dead-lettered.
+type DeadLetter struct {
+	Message
+	// Reason is why the message was first dead-lettered: rejected, expired, maxlen or delivery_limit.
+	Reason string
+	// OriginalQueue is the queue the message was first dead-lettered from.
+	OriginalQueue string
+	// OriginalExchange is the exchange the message was originally published to.
+	OriginalExchange string
+	// OriginalRoutingKeys are the routing keys the message was originally published with.
+	OriginalRoutingKeys []string
+	// DeathCount is how many times the message was dead-lettered from OriginalQueue.
+	DeathCount int64
+	// DeadLetteredAt is when the message was first dead-lettered.
+	DeadLetteredAt time.Time
+}
+
+// DeadLetterFilter selects dead letters. Zero fields match everything.
+type DeadLetterFilter struct {
+	// Headers matches messages whose headers contain every key with the given value,
+	// compared in their fmt %v form.
+	Headers map[string]string
+	// Reason matches the dead-letter reason (e.g. "rejected" or "expired").
+	Reason string
+	// OriginalQueue matches the queue the message was dead-lettered from.
+	OriginalQueue string
+	// OlderThan matches messages dead-lettered at least this long ago.
+	OlderThan time.Duration
+	// NewerThan matches messages dead-lettered at most this long ago.
+	NewerThan time.Duration
+	// Match is an optional custom predicate applied after the other fields.
+	Match func(*DeadLetter) bool
+}
+
+// matches reports whether d passes the filter at time now.
+func (f DeadLetterFilter) matches(d *DeadLetter, now time.Time) bool {
+	for key, want := range f.Headers {
+		got, ok := d.Headers[key]
+		if !ok || fmt.Sprintf("%v", got) != want {
+			return false
+		}
+	}
+	if f.Reason != "" && d.Reason != f.Reason {
+		return false
+	}
+	if f.OriginalQueue != "" && d.OriginalQueue != f.OriginalQueue {
+		return false
+	}
+	age := now.Sub(d.DeadLetteredAt)
+	if f.OlderThan > 0 && age < f.OlderThan {
+		return false
+	}
+	if f.NewerThan > 0 && age > f.NewerThan {
+		return false
+	}
+	return f.Match == nil || f.Match(d)
+}
+
+// ReplayCfg configures DeadLetterQueue.Replay.
+type ReplayCfg struct {
+	// Filter selects the messages to replay; the others stay in the dead-letter queue.
+	Filter DeadLetterFilter
+	// Limit is the maximum number of messages to replay. Zero replays every match.
+	Limit int
+	// PerSecond limits the replay rate. Zero means unlimited.
+	PerSecond float64
+	// Queue republishes every message to this queue instead of its original queue.
+	Queue string
+	// ToExchange republishes messages to their original exchange and routing key instead
+	// of directly to their original queue. Other queues bound to the exchange receive
+	// the messages again.
+	ToExchange bool
+}
+
+// ReplayResult summarizes a replay.
+type ReplayResult struct {
+	// Replayed is the number of messages republished and removed from the dead-letter queue.
+	Replayed int
+	// Skipped is the number of scanned messages left in the dead-letter queue.
+	Skipped int
+}
+
+// DeadLetterQueue inspects and replays the messages of a dead-letter queue.
+type DeadLetterQueue struct {
+	client *client
+	name   string
+}
+
+// NewDeadLetterQueue returns the dead-letter tooling for queue name.
+// c must be a Client created by NewClient.
+func NewDeadLetterQueue(c Client, name string) (*DeadLetterQueue, error) {
+	k, ok := c.(*client)
+	if !ok {
+		return nil, errors.New("dead-letter tooling requires a client created by NewClient")
+	}
+	if name == "" {
+		return nil, errors.New("queue name cannot be empty")
+	}
+	return &DeadLetterQueue{client: k, name: name}, nil
+}
+
+// Peek returns up to limit dead letters matching filter (all of them when limit is
+// zero) without removing them: messages are fetched with basic.get and requeued once
+// the scan ends. While the scan runs, scanned messages are not delivered to other
+// consumers of the queue.
+func (q *DeadLetterQueue) Peek(ctx context.Context, filter DeadLetterFilter, limit int) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	now := time.Now()
+
+	err := q.client.withChannel(func(ch *amqp091.Channel) error {
+		return q.scan(ctx, ch, func(_ *amqp091.Delivery, d *DeadLetter) (bool, bool, error) {
+			if filter.matches(d, now) {
+				letters = append(letters, *d)
+			}
+			return false, limit <= 0 || len(letters) < limit, nil
+		})
+	})
+	if err != nil {
+		return letters, adminError("peek dead letters", q.name, err)
+	}
+	return letters, nil
+}
+
+// Replay republishes the dead letters matching cfg.Filter to their original queue (or
+// exchange) and removes them from the dead-letter queue once the broker confirms the
+// publish. Messages that do not match, or whose origin is unknown, stay in the queue.
+func (q *DeadLetterQueue) Replay(ctx context.Context, cfg ReplayCfg) (ReplayResult, error) {
+	var result ReplayResult
+	var limiter RateLimiter
+	if cfg.PerSecond > 0 {
+		limiter = NewRateLimiter(cfg.PerSecond, 1)
+	}
+	now := time.Now()
+
+	err := q.client.withChannel(func(ch *amqp091.Channel) error {
+		if err := ch.Confirm(false); err != nil {
+			return fmt.Errorf("error enabling publisher confirms: %w", err)
+		}
+
+		return q.scan(ctx, ch, func(msg *amqp091.Delivery, d *DeadLetter) (bool, bool, error) {
+			if !cfg.Filter.matches(d, now) {
+				result.Skipped++
+				return false, true, nil
+			}
+
+			exchange, routingKey, ok := cfg.Target(d)
+			if !ok {
+				q.client.logger.Warn("skipping dead letter with unknown origin",
+					slog.String("queue", q.name),
+					slog.String("messageId", d.MessageID))
+				result.Skipped++
+				return false, true, nil
+			}
+
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return false, false, err
+				}
+			}
+			if err := republish(ctx, ch, exchange, routingKey, msg); err != nil {
+				return false, false, err
+			}
+			if err := msg.Ack(false); err != nil {
+				return false, false, fmt.Errorf("error acking replayed message: %w", err)
+			}
+
+			result.Replayed++
+			return true, cfg.Limit <= 0 || result.Replayed < cfg.Limit, nil
+		})
+	})
+	if err != nil {
+		return result, adminError("replay dead letters", q.name, err)
+	}
+	return result, nil
+}
+
+// scan fetches the messages present in the queue when it starts, one at a time, and
+// passes them to visit, which reports whether it settled the message and whether to
+// continue. Unsettled messages are requeued when the scan ends.
+func (q *DeadLetterQueue) scan(ctx context.Context, ch *amqp091.Channel, visit func(*amqp091.Delivery, *DeadLetter) (settled, more bool, err error)) error {
+	info, err := ch.QueueDeclarePassive(q.name, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	var lastUnsettled uint64
+	defer func() {
+		if lastUnsettled == 0 {
+			return
+		}
+		if err := ch.Nack(lastUnsettled, true, true); err != nil {
+			q.client.logger.Error("failed to requeue scanned dead letters", slog.String("error", err.Error()))
+		}
+	}()
+
+	for range info.Messages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		msg, ok, err := ch.Get(q.name, false)
+		if err != nil {
+			return fmt.Errorf("error getting message: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		settled, more, err := visit(&msg, newDeadLetter(&msg))
+		if !settled {
+			lastUnsettled = msg.DeliveryTag
+		}
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// Target returns the exchange and routing key Replay republishes d to; an empty
+// exchange means the queue named by routingKey. It returns false when the origin of d
+// is unknown, in which case Replay leaves it in the dead-letter queue.
+func (cfg ReplayCfg) Target(d *DeadLetter) (exchange, routingKey string, ok bool) {
+	switch {
+	case cfg.Queue != "":
+		return "", cfg.Queue, true
+	case cfg.ToExchange && d.OriginalExchange != "":
+		if len(d.OriginalRoutingKeys) > 0 {
+			routingKey = d.OriginalRoutingKeys[0]
+		}
+		return d.OriginalExchange, routingKey, true
+	default:
+		return "", d.OriginalQueue, d.OriginalQueue != ""
+	}
+}
+
+// republish publishes a copy of msg and waits for the broker confirmation.
+func republish(ctx context.Context, ch *amqp091.Channel, exchange, routingKey string, msg *amqp091.Delivery) error {
+	confirm, err := ch.PublishWithDeferredConfirm(exchange, routingKey, false, false, amqp091.Publishing{
+		Headers:         msg.Headers,
+		ContentType:     msg.ContentType,
+		ContentEncoding: msg.ContentEncoding,
+		DeliveryMode:    msg.DeliveryMode,
+		Priority:        msg.Priority,
+		CorrelationId:   msg.CorrelationId,
+		ReplyTo:         msg.ReplyTo,
+		MessageId:       msg.MessageId,
+		Timestamp:       msg.Timestamp,
+		Type:            msg.Type,
+		AppId:           msg.AppId,
+		Body:            msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("error republishing message: %w", err)
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error waiting for publish confirmation: %w", err)
+	}
+	if !acked {
+		return errors.New("broker rejected the republished message")
+	}
+	return nil
+}
+
+// newDeadLetter reads the dead-letter headers of a delivery.
+func newDeadLetter(msg *amqp091.Delivery) *DeadLetter {
+	d := &DeadLetter{
+		Message:        *newMessage(msg),
+		OriginalQueue:  headerString(msg.Headers, "x-first-death-queue"),
+		DeadLetteredAt: msg.Timestamp,
+	}
+
+	// x-death holds one entry per queue and reason, most recent first, so the last
+	// entry for the original queue describes the first death
+	deaths, _ := msg.Headers["x-death"].([]any)
+	for _, entry := range deaths {
+		death, ok := entry.(amqp091.Table)
+		if !ok {
+			continue
+		}
+		queue := headerString(death, "queue")
+		if d.OriginalQueue != "" && queue != d.OriginalQueue {
+			continue
+		}
+
+		d.OriginalQueue = queue
+		d.Reason = headerString(death, "reason")
+		d.OriginalExchange = headerString(death, "exchange")
+		if count, ok := death["count"].(int64); ok {
+			d.DeathCount = count
+		}
+		if at, ok := death["time"].(time.Time); ok {
+			d.DeadLetteredAt = at
+		}
+		d.OriginalRoutingKeys = nil
+		keys, _ := death["routing-keys"].([]any)
+		for _, key := range keys {
+			if s, ok := key.(string); ok {
+				d.OriginalRoutingKeys = append(d.OriginalRoutingKeys, s)
+			}
+		}
+	}
+
+	if reason := headerString(msg.Headers, "x-first-death-reason"); reason != "" {
+		d.Reason = reason
+	}
+	if exchange := headerString(msg.Headers, "x-first-death-exchange"); exchange != "" {
+		d.OriginalExchange = exchange
+	}
+	return d
+}
+
+// headerString returns a string header value, or "" when missing.
+func headerString(headers amqp091.Table, key string) string {
+	s, _ := headers[key].(string)
+	return s
+}
@@ -0,0 +1,98 @@
+package rabbitmq
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestReplayCfgTarget(t *testing.T) {
+	d := &DeadLetter{OriginalQueue: "orders", OriginalExchange: "events", OriginalRoutingKeys: []string{"order.created"}}
+
+	for _, tc := range []struct {
+		name         string
+		cfg          ReplayCfg
+		letter       *DeadLetter
+		wantExchange string
+		wantKey      string
+		wantOK       bool
+	}{
+		{name: "original queue", letter: d, wantKey: "orders", wantOK: true},
+		{name: "queue override", cfg: ReplayCfg{Queue: "orders-retry", ToExchange: true}, letter: d, wantKey: "orders-retry", wantOK: true},
+		{name: "original exchange", cfg: ReplayCfg{ToExchange: true}, letter: d, wantExchange: "events", wantKey: "order.created", wantOK: true},
+		{name: "default exchange falls back to the queue", cfg: ReplayCfg{ToExchange: true}, letter: &DeadLetter{OriginalQueue: "orders"}, wantKey: "orders", wantOK: true},
+		{name: "unknown origin", letter: &DeadLetter{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exchange, routingKey, ok := tc.cfg.Target(tc.letter)
+			if exchange != tc.wantExchange || routingKey != tc.wantKey || ok != tc.wantOK {
+				t.Fatalf("Target = %q, %q, %t, want %q, %q, %t", exchange, routingKey, ok, tc.wantExchange, tc.wantKey, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewDeadLetterReadsFirstDeath(t *testing.T) {
+	firstDeath := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := &amqp091.Delivery{
+		MessageId: "msg-1",
+		Headers: amqp091.Table{
+			"x-first-death-queue":  "orders",
+			"x-first-death-reason": "rejected",
+			"x-death": []any{
+				amqp091.Table{"queue": "orders-retry", "reason": "expired", "exchange": "", "count": int64(1)},
+				amqp091.Table{
+					"queue":        "orders",
+					"reason":       "rejected",
+					"exchange":     "events",
+					"count":        int64(3),
+					"time":         firstDeath,
+					"routing-keys": []any{"order.created"},
+				},
+			},
+		},
+	}
+
+	d := newDeadLetter(msg)
+	if d.OriginalQueue != "orders" || d.OriginalExchange != "events" || d.Reason != "rejected" {
+		t.Fatalf("origin = %q/%q (%s), want orders/events (rejected)", d.OriginalQueue, d.OriginalExchange, d.Reason)
+	}
+	if d.DeathCount != 3 || !d.DeadLetteredAt.Equal(firstDeath) {
+		t.Fatalf("deaths = %d at %s, want 3 at %s", d.DeathCount, d.DeadLetteredAt, firstDeath)
+	}
+	if !slices.Equal(d.OriginalRoutingKeys, []string{"order.created"}) {
+		t.Fatalf("routing keys = %v", d.OriginalRoutingKeys)
+	}
+}
+
+func TestDeadLetterFilterMatches(t *testing.T) {
+	now := time.Now()
+	d := &DeadLetter{
+		Message:        Message{Headers: map[string]any{"tenant": int64(42)}},
+		Reason:         "rejected",
+		OriginalQueue:  "orders",
+		DeadLetteredAt: now.Add(-time.Hour),
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter DeadLetterFilter
+		want   bool
+	}{
+		{name: "empty", want: true},
+		{name: "header", filter: DeadLetterFilter{Headers: map[string]string{"tenant": "42"}}, want: true},
+		{name: "other header value", filter: DeadLetterFilter{Headers: map[string]string{"tenant": "7"}}},
+		{name: "reason", filter: DeadLetterFilter{Reason: "expired"}},
+		{name: "queue", filter: DeadLetterFilter{OriginalQueue: "orders"}, want: true},
+		{name: "older than", filter: DeadLetterFilter{OlderThan: 30 * time.Minute}, want: true},
+		{name: "too recent", filter: DeadLetterFilter{OlderThan: 2 * time.Hour}},
+		{name: "too old", filter: DeadLetterFilter{NewerThan: 30 * time.Minute}},
+		{name: "predicate", filter: DeadLetterFilter{Match: func(*DeadLetter) bool { return false }}},
+	} {
+		if got := tc.filter.matches(d, now); got != tc.want {
+			t.Errorf("%s: matches = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
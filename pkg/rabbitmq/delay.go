@@ -0,0 +1,112 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// headerDelay carries the delay of a message in milliseconds, as expected by the
+	// x-delayed-message exchange.
+	headerDelay = "x-delay"
+
+	defaultDelayExchange    = "delayed"
+	defaultDelayQueuePrefix = "delay."
+
+	// delayQueueGrace keeps TTL hold queues alive for a while after their last message
+	// expired, so a steady flow of delayed messages does not recreate them constantly.
+	delayQueueGrace = time.Minute
+)
+
+// DelayStrategy selects how the broker holds delayed messages until they are due.
+type DelayStrategy int
+
+const (
+	// DelayStrategyTTL holds delayed messages in a queue per target queue and delay,
+	// whose message TTL dead-letters them into the target queue. It needs no broker
+	// plugin, but delays are rounded up to whole seconds and every distinct delay
+	// creates a queue, so prefer a few fixed delays.
+	DelayStrategyTTL DelayStrategy = iota
+	// DelayStrategyPlugin publishes delayed messages to an x-delayed-message exchange
+	// bound to the target queues. It supports any delay with millisecond precision
+	// and requires the rabbitmq_delayed_message_exchange plugin.
+	DelayStrategyPlugin
+)
+
+// DelayCfg configures how producers publish messages with WithDelay or WithDeliverAt.
+type DelayCfg struct {
+	// Strategy selects how delayed messages are held. Defaults to DelayStrategyTTL.
+	Strategy DelayStrategy
+	// Exchange is the x-delayed-message exchange used by DelayStrategyPlugin.
+	// Defaults to "delayed".
+	Exchange string
+	// QueuePrefix is prepended to the hold queues of DelayStrategyTTL, which are named
+	// <QueuePrefix><queue>.<delay>s. Defaults to "delay.".
+	QueuePrefix string
+}
+
+// delayChannel is the part of *amqp091.Channel used to declare what delay strategies need.
+type delayChannel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp091.Table) error
+	QueueBind(name, key, exchange string, noWait bool, args amqp091.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp091.Table) (amqp091.Queue, error)
+}
+
+type delayer struct {
+	cfg DelayCfg
+}
+
+func newDelayer(cfg DelayCfg) *delayer {
+	if cfg.Exchange == "" {
+		cfg.Exchange = defaultDelayExchange
+	}
+	if cfg.QueuePrefix == "" {
+		cfg.QueuePrefix = defaultDelayQueuePrefix
+	}
+	return &delayer{cfg: cfg}
+}
+
+// messageDelay returns the delay requested for msg, if any.
+func messageDelay(msg *amqp091.Publishing) (time.Duration, bool) {
+	ms, ok := msg.Headers[headerDelay].(int64)
+	if !ok || ms <= 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// route declares what the strategy needs to hold a message for delay before it reaches
+// queueName, and returns the exchange and routing key to publish it with. Like target
+// queues, the declarations are repeated on every publish, which also keeps TTL hold
+// queues from expiring while in use.
+func (d *delayer) route(ch delayChannel, queueName string, delay time.Duration) (string, string, error) {
+	if d.cfg.Strategy == DelayStrategyPlugin {
+		err := ch.ExchangeDeclare(d.cfg.Exchange, "x-delayed-message", true, false, false, false,
+			amqp091.Table{"x-delayed-type": "direct"})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to declare delayed exchange %q: %w", d.cfg.Exchange, err)
+		}
+		if err := ch.QueueBind(queueName, queueName, d.cfg.Exchange, false, nil); err != nil {
+			return "", "", fmt.Errorf("failed to bind queue to delayed exchange %q: %w", d.cfg.Exchange, err)
+		}
+		return d.cfg.Exchange, queueName, nil
+	}
+
+	seconds := int64((delay + time.Second - 1) / time.Second)
+	ttl := seconds * int64(time.Second/time.Millisecond)
+	hold := fmt.Sprintf("%s%s.%ds", d.cfg.QueuePrefix, queueName, seconds)
+
+	_, err := ch.QueueDeclare(hold, true, false, false, false, amqp091.Table{
+		"x-message-ttl":             ttl,
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queueName,
+		// Expire the hold queue once unused for longer than its messages can live
+		"x-expires": ttl + delayQueueGrace.Milliseconds(),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to declare delay queue %q: %w", hold, err)
+	}
+	return "", hold, nil
+}
@@ -0,0 +1,177 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// declaration records a call made by a delay strategy on its channel.
+type declaration struct {
+	method   string
+	name     string
+	kind     string
+	key      string
+	exchange string
+	durable  bool
+	args     amqp091.Table
+}
+
+// recordingChannel records the declarations of a delay strategy and fails them with err.
+type recordingChannel struct {
+	declared []declaration
+	err      error
+}
+
+func (c *recordingChannel) ExchangeDeclare(name, kind string, durable, _, _, _ bool, args amqp091.Table) error {
+	c.declared = append(c.declared, declaration{method: "ExchangeDeclare", name: name, kind: kind, durable: durable, args: args})
+	return c.err
+}
+
+func (c *recordingChannel) QueueBind(name, key, exchange string, _ bool, args amqp091.Table) error {
+	c.declared = append(c.declared, declaration{method: "QueueBind", name: name, key: key, exchange: exchange, args: args})
+	return c.err
+}
+
+func (c *recordingChannel) QueueDeclare(name string, durable, _, _, _ bool, args amqp091.Table) (amqp091.Queue, error) {
+	c.declared = append(c.declared, declaration{method: "QueueDeclare", name: name, durable: durable, args: args})
+	return amqp091.Queue{Name: name}, c.err
+}
+
+func TestDelayTTLHoldQueues(t *testing.T) {
+	for _, tc := range []struct {
+		cfg      DelayCfg
+		delay    time.Duration
+		wantName string
+		wantTTL  int64
+	}{
+		{delay: 5 * time.Second, wantName: "delay.orders.5s", wantTTL: 5000},
+		{delay: 1500 * time.Millisecond, wantName: "delay.orders.2s", wantTTL: 2000},
+		{delay: time.Millisecond, wantName: "delay.orders.1s", wantTTL: 1000},
+		{delay: time.Hour, wantName: "delay.orders.3600s", wantTTL: 3_600_000},
+		{cfg: DelayCfg{QueuePrefix: "hold-"}, delay: time.Minute, wantName: "hold-orders.60s", wantTTL: 60_000},
+	} {
+		t.Run(tc.wantName, func(t *testing.T) {
+			ch := &recordingChannel{}
+			exchange, routingKey, err := newDelayer(tc.cfg).route(ch, "orders", tc.delay)
+			if err != nil {
+				t.Fatalf("route: %v", err)
+			}
+			if exchange != "" || routingKey != tc.wantName {
+				t.Fatalf("route = (%q, %q), want the default exchange and %q", exchange, routingKey, tc.wantName)
+			}
+
+			if len(ch.declared) != 1 || ch.declared[0].method != "QueueDeclare" {
+				t.Fatalf("declared %+v, want a single hold queue", ch.declared)
+			}
+			hold := ch.declared[0]
+			if hold.name != tc.wantName || !hold.durable {
+				t.Fatalf("hold queue = %q (durable %v), want durable %q", hold.name, hold.durable, tc.wantName)
+			}
+			want := amqp091.Table{
+				"x-message-ttl":             tc.wantTTL,
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": "orders",
+				"x-expires":                 tc.wantTTL + time.Minute.Milliseconds(),
+			}
+			if fmt.Sprint(hold.args) != fmt.Sprint(want) {
+				t.Fatalf("hold queue arguments = %v, want %v", hold.args, want)
+			}
+		})
+	}
+}
+
+func TestDelayPluginExchange(t *testing.T) {
+	for _, tc := range []struct {
+		cfg          DelayCfg
+		wantExchange string
+	}{
+		{cfg: DelayCfg{Strategy: DelayStrategyPlugin}, wantExchange: "delayed"},
+		{cfg: DelayCfg{Strategy: DelayStrategyPlugin, Exchange: "later"}, wantExchange: "later"},
+	} {
+		t.Run(tc.wantExchange, func(t *testing.T) {
+			ch := &recordingChannel{}
+			exchange, routingKey, err := newDelayer(tc.cfg).route(ch, "orders", 1500*time.Millisecond)
+			if err != nil {
+				t.Fatalf("route: %v", err)
+			}
+			if exchange != tc.wantExchange || routingKey != "orders" {
+				t.Fatalf("route = (%q, %q), want (%q, %q)", exchange, routingKey, tc.wantExchange, "orders")
+			}
+
+			want := []declaration{
+				{method: "ExchangeDeclare", name: tc.wantExchange, kind: "x-delayed-message", durable: true, args: amqp091.Table{"x-delayed-type": "direct"}},
+				{method: "QueueBind", name: "orders", key: "orders", exchange: tc.wantExchange},
+			}
+			if fmt.Sprint(ch.declared) != fmt.Sprint(want) {
+				t.Fatalf("declared %+v, want %+v", ch.declared, want)
+			}
+		})
+	}
+}
+
+func TestDelayRouteReturnsDeclarationErrors(t *testing.T) {
+	errBroker := errors.New("access refused")
+	for _, strategy := range []DelayStrategy{DelayStrategyTTL, DelayStrategyPlugin} {
+		ch := &recordingChannel{err: errBroker}
+		if _, _, err := newDelayer(DelayCfg{Strategy: strategy}).route(ch, "orders", time.Second); !errors.Is(err, errBroker) {
+			t.Errorf("strategy %d: route = %v, want the declaration error", strategy, err)
+		}
+	}
+}
+
+func TestMessageDelay(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		opts    []PublishOption
+		headers amqp091.Table
+		want    time.Duration
+		delayed bool
+	}{
+		{name: "no headers"},
+		{name: "no delay header", headers: amqp091.Table{"other": int64(5)}},
+		{name: "WithDelay", opts: []PublishOption{WithDelay(1500 * time.Millisecond)}, want: 1500 * time.Millisecond, delayed: true},
+		{name: "zero delay", opts: []PublishOption{WithDelay(0)}},
+		{name: "negative delay", opts: []PublishOption{WithDelay(-time.Minute)}},
+		{name: "sub-millisecond delay", opts: []PublishOption{WithDelay(time.Microsecond)}},
+		{name: "past WithDeliverAt", opts: []PublishOption{WithDeliverAt(time.Now().Add(-time.Hour))}},
+		{name: "future WithDeliverAt", opts: []PublishOption{WithDeliverAt(time.Now().Add(time.Hour))}, want: time.Hour, delayed: true},
+		{name: "non-integer header", headers: amqp091.Table{headerDelay: "1000"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := amqp091.Publishing{Headers: tc.headers}
+			for _, opt := range tc.opts {
+				opt(&msg)
+			}
+
+			got, delayed := messageDelay(&msg)
+			if delayed != tc.delayed {
+				t.Fatalf("messageDelay delayed = %v, want %v", delayed, tc.delayed)
+			}
+			// WithDeliverAt is computed against the clock, so allow for the time the test took
+			if got > tc.want || got < tc.want-time.Second {
+				t.Fatalf("messageDelay = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProducerRejectsDelayedPublishToExchange(t *testing.T) {
+	p := newTestClient().newProducer()
+
+	err := p.PublishToExchange(context.Background(), "events", "order.created", map[string]string{}, WithDelay(time.Minute))
+	if err == nil || !strings.Contains(err.Error(), "only be published to queues") {
+		t.Fatalf("delayed publish to an exchange = %v, want it rejected", err)
+	}
+
+	// A zero delay is no delay: the publish goes ahead and only fails for lack of a connection.
+	err = p.PublishToExchange(context.Background(), "events", "order.created", map[string]string{}, WithDelay(0))
+	if err == nil || strings.Contains(err.Error(), "only be published to queues") {
+		t.Fatalf("publish with a zero delay = %v, want it attempted", err)
+	}
+}
@@ -0,0 +1,167 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownMessageType is returned by a Dispatcher for messages whose type has no
+// registered handler, unless the unknown type policy acknowledges them.
+var ErrUnknownMessageType = errors.New("unknown message type")
+
+// UnknownTypePolicy selects what a Dispatcher does with messages whose type has no handler.
+type UnknownTypePolicy int
+
+const (
+	// UnknownTypePolicyDeadLetter rejects the message without requeue (ErrDeadLetter).
+	UnknownTypePolicyDeadLetter UnknownTypePolicy = iota
+	// UnknownTypePolicyAck acknowledges and drops the message.
+	UnknownTypePolicyAck
+	// UnknownTypePolicyRequeue nacks the message and requeues it, e.g. while a newer
+	// version of the worker that knows the type is being rolled out.
+	UnknownTypePolicyRequeue
+)
+
+// Dispatcher is a ConsumerHandler that routes each message to the handler registered
+// for its type. By default the type is read from the AMQP type property (see WithType);
+// use WithTypeHeader or WithTypeField to read it from a header or a JSON body field.
+type Dispatcher struct {
+	typeOf   func(*Message) string
+	unknown  UnknownTypePolicy
+	mu       sync.RWMutex
+	handlers map[string]ContextHandler
+}
+
+// NewDispatcher creates a dispatcher with no handlers.
+func NewDispatcher(opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		typeOf:   func(msg *Message) string { return msg.Type },
+		handlers: make(map[string]ContextHandler),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Handle registers handler for messages of msgType. Handlers implementing
+// ContextHandler receive the message with its properties.
+func (d *Dispatcher) Handle(msgType string, handler ConsumerHandler) error {
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+	if h, ok := handler.(ContextHandler); ok {
+		return d.register(msgType, h)
+	}
+	return d.HandleFunc(msgType, func(_ context.Context, msg *Message) error {
+		return handler.HandleMessage(msg.Body)
+	})
+}
+
+// HandleFunc registers fn for messages of msgType.
+func (d *Dispatcher) HandleFunc(msgType string, fn func(ctx context.Context, msg *Message) error) error {
+	if fn == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+	return d.register(msgType, handlerFunc(fn))
+}
+
+// HandleJSON registers fn for messages of msgType, decoding their JSON body into T.
+// Messages that cannot be decoded are dead-lettered, as retrying them cannot succeed.
+func HandleJSON[T any](d *Dispatcher, msgType string, fn func(ctx context.Context, payload T, msg *Message) error) error {
+	if fn == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+	return d.HandleFunc(msgType, func(ctx context.Context, msg *Message) error {
+		var payload T
+		if err := json.Unmarshal(msg.Body, &payload); err != nil {
+			return fmt.Errorf("%w: error decoding %q message: %w", ErrDeadLetter, msgType, err)
+		}
+		return fn(ctx, payload, msg)
+	})
+}
+
+func (d *Dispatcher) register(msgType string, h ContextHandler) error {
+	if msgType == "" {
+		return fmt.Errorf("message type cannot be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.handlers[msgType]; ok {
+		return fmt.Errorf("handler for message type %q is already registered", msgType)
+	}
+	d.handlers[msgType] = h
+
+	return nil
+}
+
+// HandleMessage dispatches a payload without properties, so only the JSON field type
+// source can resolve its type. Consumers call HandleMessageContext instead.
+func (d *Dispatcher) HandleMessage(body []byte) error {
+	return d.HandleMessageContext(context.Background(), &Message{Body: body})
+}
+
+// HandleMessageContext dispatches msg to the handler registered for its type.
+func (d *Dispatcher) HandleMessageContext(ctx context.Context, msg *Message) error {
+	msgType := d.typeOf(msg)
+
+	d.mu.RLock()
+	h, ok := d.handlers[msgType]
+	d.mu.RUnlock()
+
+	if ok {
+		return h.HandleMessageContext(ctx, msg)
+	}
+
+	switch d.unknown {
+	case UnknownTypePolicyAck:
+		return nil
+	case UnknownTypePolicyRequeue:
+		return fmt.Errorf("%w %q", ErrUnknownMessageType, msgType)
+	default:
+		return fmt.Errorf("%w: %w %q", ErrDeadLetter, ErrUnknownMessageType, msgType)
+	}
+}
+
+// handlerFunc adapts a function to ContextHandler.
+type handlerFunc func(ctx context.Context, msg *Message) error
+
+func (f handlerFunc) HandleMessageContext(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// headerType returns a type source reading the string header key.
+func headerType(key string) func(*Message) string {
+	return func(msg *Message) string {
+		s, _ := msg.Headers[key].(string)
+		return s
+	}
+}
+
+// fieldType returns a type source reading the string field at a dot-separated path of
+// a JSON object body, e.g. "type" or "meta.eventType". Bodies that are not JSON objects
+// and fields that are missing or not strings resolve to the empty type.
+func fieldType(path string) func(*Message) string {
+	keys := strings.Split(path, ".")
+	return func(msg *Message) string {
+		raw := json.RawMessage(msg.Body)
+		for _, key := range keys {
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return ""
+			}
+			raw = obj[key]
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return ""
+		}
+		return s
+	}
+}
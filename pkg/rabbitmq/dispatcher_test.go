@@ -0,0 +1,155 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFieldType(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		body string
+		want string
+	}{
+		{path: "type", body: `{"type":"order.created"}`, want: "order.created"},
+		{path: "meta.eventType", body: `{"meta":{"eventType":"order.paid"}}`, want: "order.paid"},
+		{path: "meta.eventType", body: `{"meta":{}}`, want: ""},
+		{path: "meta.eventType", body: `{"other":1}`, want: ""},
+		{path: "meta.eventType", body: `{"meta":"order.paid"}`, want: ""},
+		{path: "type", body: `{"type":42}`, want: ""},
+		{path: "type", body: `{"type":{"name":"x"}}`, want: ""},
+		{path: "type", body: `["order.created"]`, want: ""},
+		{path: "type", body: `"order.created"`, want: ""},
+		{path: "type", body: `not json`, want: ""},
+		{path: "type", body: ``, want: ""},
+	} {
+		if got := fieldType(tc.path)(&Message{Body: []byte(tc.body)}); got != tc.want {
+			t.Errorf("fieldType(%q) of %s = %q, want %q", tc.path, tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestDispatcherTypeSources(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []DispatcherOption
+		msg  *Message
+	}{
+		{name: "property", msg: &Message{Type: "order.created"}},
+		{name: "header", opts: []DispatcherOption{WithTypeHeader("event")}, msg: &Message{Headers: map[string]any{"event": "order.created"}}},
+		{name: "field", opts: []DispatcherOption{WithTypeField("type")}, msg: &Message{Body: []byte(`{"type":"order.created"}`)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDispatcher(tc.opts...)
+			var got *Message
+			if err := d.HandleFunc("order.created", func(_ context.Context, msg *Message) error {
+				got = msg
+				return nil
+			}); err != nil {
+				t.Fatalf("HandleFunc: %v", err)
+			}
+
+			if err := d.HandleMessageContext(context.Background(), tc.msg); err != nil || got != tc.msg {
+				t.Fatalf("HandleMessageContext = %v, handler got %v", err, got)
+			}
+		})
+	}
+}
+
+func TestDispatcherUnknownTypePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		policy         UnknownTypePolicy
+		wantErr        bool
+		wantDeadLetter bool
+	}{
+		{name: "dead letter", policy: UnknownTypePolicyDeadLetter, wantErr: true, wantDeadLetter: true},
+		{name: "ack", policy: UnknownTypePolicyAck},
+		{name: "requeue", policy: UnknownTypePolicyRequeue, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDispatcher(WithUnknownTypePolicy(tc.policy))
+			err := d.HandleMessageContext(context.Background(), &Message{Type: "order.refunded"})
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("HandleMessageContext = %v, want error %v", err, tc.wantErr)
+			}
+			if tc.wantErr && !errors.Is(err, ErrUnknownMessageType) {
+				t.Fatalf("error %v does not wrap ErrUnknownMessageType", err)
+			}
+			if errors.Is(err, ErrDeadLetter) != tc.wantDeadLetter {
+				t.Fatalf("error %v wraps ErrDeadLetter = %v, want %v", err, !tc.wantDeadLetter, tc.wantDeadLetter)
+			}
+		})
+	}
+}
+
+func TestHandleJSON(t *testing.T) {
+	type order struct {
+		ID string `json:"id"`
+	}
+	d := NewDispatcher()
+	var got order
+	if err := HandleJSON(d, "order.created", func(_ context.Context, payload order, _ *Message) error {
+		got = payload
+		return nil
+	}); err != nil {
+		t.Fatalf("HandleJSON: %v", err)
+	}
+
+	if err := d.HandleMessageContext(context.Background(), &Message{Type: "order.created", Body: []byte(`{"id":"42"}`)}); err != nil || got.ID != "42" {
+		t.Fatalf("HandleMessageContext = %v, payload %+v", err, got)
+	}
+
+	err := d.HandleMessageContext(context.Background(), &Message{Type: "order.created", Body: []byte(`{"id":42}`)})
+	if !errors.Is(err, ErrDeadLetter) {
+		t.Fatalf("undecodable payload = %v, want ErrDeadLetter", err)
+	}
+}
+
+func TestDispatcherRegistration(t *testing.T) {
+	d := NewDispatcher()
+	ok := testHandler(func([]byte) error { return nil })
+
+	if err := d.Handle("order.created", ok); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := d.Handle("order.created", ok); err == nil {
+		t.Fatal("registered the same type twice")
+	}
+	if err := HandleJSON(d, "order.created", func(context.Context, struct{}, *Message) error { return nil }); err == nil {
+		t.Fatal("HandleJSON registered an already handled type")
+	}
+	if err := d.Handle("", ok); err == nil {
+		t.Fatal("registered an empty type")
+	}
+	if err := d.Handle("order.paid", nil); err == nil {
+		t.Fatal("registered a nil handler")
+	}
+	if err := d.HandleFunc("order.paid", nil); err == nil {
+		t.Fatal("registered a nil func")
+	}
+}
+
+func TestDispatcherHandleMessageWithoutProperties(t *testing.T) {
+	var handled []byte
+	handler := testHandler(func(body []byte) error {
+		handled = body
+		return nil
+	})
+
+	// Only the JSON field type source can resolve a payload without properties.
+	d := NewDispatcher(WithTypeField("type"))
+	_ = d.Handle("order.created", handler)
+	body := []byte(`{"type":"order.created"}`)
+	if err := d.HandleMessage(body); err != nil || string(handled) != string(body) {
+		t.Fatalf("HandleMessage = %v, handled %s", err, handled)
+	}
+
+	d = NewDispatcher()
+	_ = d.Handle("order.created", handler)
+	if err := d.HandleMessage(body); !errors.Is(err, ErrUnknownMessageType) {
+		t.Fatalf("HandleMessage with the property type source = %v, want ErrUnknownMessageType", err)
+	}
+}
@@ -6,10 +6,28 @@
 // Features:
 //   - Automatic channel reconnection for producers
 //   - Concurrent message processing for consumers
+//   - Batch consumption with multiple=true acknowledgements
+//   - Stream queues with offset tracking (NewStreamConsumer, DeclareStream)
+//   - Priority queues (DeclarePriorityQueue, WithPriority)
+//   - Blocked-connection backpressure for producers (WithBlockedPolicy)
+//   - Queue management (InspectQueue, PurgeQueue, DeleteQueue, UnbindQueue, DeleteExchange)
+//   - Dead-letter inspection and replay (NewDeadLetterQueue, cmd/rabbitmq-dlq)
+//   - Multi-queue worker router with restarts and coordinated shutdown (NewRouter)
+//   - Message-type dispatch to typed handlers (NewDispatcher, HandleJSON, WithType)
+//   - Transparent gzip/zstd payload compression (WithCompression)
+//   - AES-GCM envelope encryption with key rotation (WithEncryption, KeyProvider)
+//   - Claim-check offloading of large payloads (WithClaimCheck, see the rabbitmqgcs package)
+//   - Delayed publishing with TTL queues or the delayed-message plugin (WithDelay, WithDeliverAt)
+//   - Graceful producer shutdown draining publisher confirms (Shutdown, WithChannelCloseHandler)
+//   - JSON Schema validation of message contracts on publish and consume (WithSchemaValidation)
+//   - Consistent-hash sharded queues with single-active-consumer shards (DeclareShardedQueues, NewShardConsumer)
 //   - Persistent messages (survive broker restarts)
 //   - Durable queues
 //   - Thread-safe producer operations
 //   - Context-aware operations
+//   - OpenTelemetry trace propagation through message headers
+//   - Pluggable metrics instrumentation (see the rabbitmqmetrics package)
+//   - In-memory broker fake for unit tests (see the rabbitmqtest package)
 //
 // Example Producer:
 //
@@ -53,6 +71,17 @@
 //		producer, _ = client.NewProducer()
 //	}
 //
+// Tracing:
+//
+// Producers start a span for every publish and inject W3C trace context
+// (traceparent/tracestate) into the AMQP headers; consumers extract it and start a
+// consumer span around the handler. Handlers implementing ContextHandler receive
+// the span context and the message properties.
+//
+//	client, _ := rabbitmq.NewClient(ctx, url, rabbitmq.WithTracerProvider(tp))
+//	producer, _ := client.NewProducer()
+//	err := producer.PublishWithContext(ctx, "orders", order)
+//
 // Graceful Shutdown:
 //
 // The consumer supports graceful shutdown via context cancellation.
@@ -0,0 +1,228 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ErrUnknownKey is returned by a KeyProvider for key IDs it does not know.
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+const (
+	// headerEncryptionKeyID carries the ID of the key-encryption key of an encrypted message.
+	headerEncryptionKeyID = "x-encryption-key-id"
+	// headerEncryptionKey carries the wrapped data key of an encrypted message.
+	headerEncryptionKey = "x-encryption-key"
+
+	dataKeySize = 32 // AES-256
+)
+
+// KeyProvider supplies the key-encryption keys used by WithEncryption. Keys must be
+// 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	// EncryptionKey returns the ID and value of the key used to encrypt new messages.
+	EncryptionKey(ctx context.Context) (keyID string, key []byte, err error)
+	// DecryptionKey returns the key with the given ID, or an error wrapping
+	// ErrUnknownKey when there is none.
+	DecryptionKey(ctx context.Context, keyID string) ([]byte, error)
+}
+
+// RotatingKeyProvider is a KeyProvider holding keys in memory. New messages are
+// encrypted with the current key; older keys stay available for decryption until retired.
+type RotatingKeyProvider struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyProvider returns a provider with a single key.
+func NewStaticKeyProvider(keyID string, key []byte) (*RotatingKeyProvider, error) {
+	return NewRotatingKeyProvider(keyID, map[string][]byte{keyID: key})
+}
+
+// NewRotatingKeyProvider returns a provider that encrypts with keys[currentID] and
+// decrypts with any key in keys.
+func NewRotatingKeyProvider(currentID string, keys map[string][]byte) (*RotatingKeyProvider, error) {
+	for keyID, key := range keys {
+		if err := validateKey(keyID, key); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := keys[currentID]; !ok {
+		return nil, fmt.Errorf("current key %q is not in keys", currentID)
+	}
+	return &RotatingKeyProvider{current: currentID, keys: maps.Clone(keys)}, nil
+}
+
+// Rotate adds key and makes it the current key. Messages encrypted with previous keys
+// can still be decrypted until those keys are retired.
+func (p *RotatingKeyProvider) Rotate(keyID string, key []byte) error {
+	if err := validateKey(keyID, key); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if existing, ok := p.keys[keyID]; ok && string(existing) != string(key) {
+		return fmt.Errorf("key %q already exists with a different value", keyID)
+	}
+	p.keys[keyID] = slices.Clone(key)
+	p.current = keyID
+
+	return nil
+}
+
+// Retire removes a previous key. Messages still encrypted with it are dead-lettered.
+func (p *RotatingKeyProvider) Retire(keyID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if keyID == p.current {
+		return fmt.Errorf("cannot retire current key %q", keyID)
+	}
+	delete(p.keys, keyID)
+
+	return nil
+}
+
+// EncryptionKey implements KeyProvider.
+func (p *RotatingKeyProvider) EncryptionKey(context.Context) (string, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current, p.keys[p.current], nil
+}
+
+// DecryptionKey implements KeyProvider.
+func (p *RotatingKeyProvider) DecryptionKey(_ context.Context, keyID string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	return key, nil
+}
+
+func validateKey(keyID string, key []byte) error {
+	if keyID == "" {
+		return fmt.Errorf("key ID cannot be empty")
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return fmt.Errorf("invalid key %q: %w", keyID, err)
+	}
+	return nil
+}
+
+// encryptPublishing encrypts the body of msg with a random data key and stores the
+// data key, wrapped with the provider's current key, in the message headers.
+func encryptPublishing(ctx context.Context, keys KeyProvider, msg *amqp091.Publishing) error {
+	if keys == nil {
+		return nil
+	}
+
+	keyID, kek, err := keys.EncryptionKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get encryption key: %w", err)
+	}
+
+	dek := make([]byte, dataKeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	body, err := seal(dek, msg.Body, nil)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt message body: %w", err)
+	}
+	wrapped, err := seal(kek, dek, []byte(keyID))
+	if err != nil {
+		return fmt.Errorf("failed to wrap data key with key %q: %w", keyID, err)
+	}
+
+	if msg.Headers == nil {
+		msg.Headers = amqp091.Table{}
+	}
+	msg.Headers[headerEncryptionKeyID] = keyID
+	msg.Headers[headerEncryptionKey] = wrapped
+	msg.Body = body
+
+	return nil
+}
+
+// decryptDelivery replaces the body of an encrypted delivery with its plaintext.
+// Unencrypted deliveries are left untouched. Messages that can never be decrypted, because
+// their key is unknown or they fail authentication, return an error wrapping ErrDeadLetter.
+func decryptDelivery(ctx context.Context, keys KeyProvider, msg *amqp091.Delivery) error {
+	keyID, ok := msg.Headers[headerEncryptionKeyID].(string)
+	if !ok {
+		return nil
+	}
+	if keys == nil {
+		return fmt.Errorf("%w: message encrypted with key %q but no key provider is configured", ErrDeadLetter, keyID)
+	}
+
+	kek, err := keys.DecryptionKey(ctx, keyID)
+	if errors.Is(err, ErrUnknownKey) {
+		return fmt.Errorf("%w: %w", ErrDeadLetter, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get decryption key %q: %w", keyID, err)
+	}
+
+	wrapped, _ := msg.Headers[headerEncryptionKey].([]byte)
+	dek, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return fmt.Errorf("%w: failed to unwrap data key with key %q: %w", ErrDeadLetter, keyID, err)
+	}
+	body, err := open(dek, msg.Body, nil)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decrypt message body: %w", ErrDeadLetter, err)
+	}
+	msg.Body = body
+
+	return nil
+}
+
+// seal encrypts plaintext with AES-GCM, prefixing the result with a random nonce.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext produced by seal.
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,150 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	testKeyA = bytes.Repeat([]byte{0xa}, 32)
+	testKeyB = bytes.Repeat([]byte{0xb}, 16)
+)
+
+// encryptForTest encrypts payload with keys and returns it as a delivery.
+func encryptForTest(t *testing.T, keys KeyProvider, payload []byte) amqp091.Delivery {
+	t.Helper()
+	msg := amqp091.Publishing{Body: payload}
+	if err := encryptPublishing(context.Background(), keys, &msg); err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	return amqp091.Delivery{Headers: msg.Headers, Body: msg.Body}
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	payload := []byte(`{"card":"4111111111111111"}`)
+	keys, err := NewStaticKeyProvider("a", testKeyA)
+	if err != nil {
+		t.Fatalf("NewStaticKeyProvider: %v", err)
+	}
+
+	delivery := encryptForTest(t, keys, payload)
+	if bytes.Contains(delivery.Body, payload) {
+		t.Fatal("encrypted body contains the plaintext")
+	}
+	if delivery.Headers[headerEncryptionKeyID] != "a" {
+		t.Fatalf("key ID header = %v, want %q", delivery.Headers[headerEncryptionKeyID], "a")
+	}
+
+	if err := decryptDelivery(context.Background(), keys, &delivery); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if !bytes.Equal(delivery.Body, payload) {
+		t.Fatalf("decrypted body = %q, want %q", delivery.Body, payload)
+	}
+}
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	payload := []byte("payload")
+	keys, err := NewRotatingKeyProvider("a", map[string][]byte{"a": testKeyA})
+	if err != nil {
+		t.Fatalf("NewRotatingKeyProvider: %v", err)
+	}
+	old := encryptForTest(t, keys, payload)
+
+	if err := keys.Rotate("b", testKeyB); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	current := encryptForTest(t, keys, payload)
+	if current.Headers[headerEncryptionKeyID] != "b" {
+		t.Fatalf("key ID header after rotation = %v, want %q", current.Headers[headerEncryptionKeyID], "b")
+	}
+	for _, delivery := range []amqp091.Delivery{old, current} {
+		if err := decryptDelivery(context.Background(), keys, &delivery); err != nil || !bytes.Equal(delivery.Body, payload) {
+			t.Fatalf("decrypt with key %v = %q, %v", delivery.Headers[headerEncryptionKeyID], delivery.Body, err)
+		}
+	}
+
+	if err := keys.Retire("b"); err == nil {
+		t.Fatal("retiring the current key succeeded")
+	}
+	if err := keys.Retire("a"); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	old = encryptForTest(t, mustStaticKeys(t, "a", testKeyA), payload)
+	if err := decryptDelivery(context.Background(), keys, &old); !errors.Is(err, ErrDeadLetter) || !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("decrypt with retired key = %v, want ErrDeadLetter wrapping ErrUnknownKey", err)
+	}
+}
+
+func TestDecryptDeliveryDeadLettersUndecryptableMessages(t *testing.T) {
+	keys := mustStaticKeys(t, "a", testKeyA)
+
+	tests := map[string]struct {
+		keys    KeyProvider
+		corrupt func(*amqp091.Delivery)
+	}{
+		"no key provider": {
+			corrupt: func(*amqp091.Delivery) {},
+		},
+		"same key ID with a different key": {
+			keys:    mustStaticKeys(t, "a", bytes.Repeat([]byte{0xc}, 32)),
+			corrupt: func(*amqp091.Delivery) {},
+		},
+		"tampered body": {
+			keys:    keys,
+			corrupt: func(d *amqp091.Delivery) { d.Body[len(d.Body)-1] ^= 1 },
+		},
+		"missing wrapped key": {
+			keys:    keys,
+			corrupt: func(d *amqp091.Delivery) { delete(d.Headers, headerEncryptionKey) },
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			delivery := encryptForTest(t, keys, []byte("payload"))
+			tt.corrupt(&delivery)
+			if err := decryptDelivery(context.Background(), tt.keys, &delivery); !errors.Is(err, ErrDeadLetter) {
+				t.Fatalf("decrypt = %v, want ErrDeadLetter", err)
+			}
+		})
+	}
+}
+
+func TestDecryptDeliveryIgnoresPlaintextMessages(t *testing.T) {
+	delivery := amqp091.Delivery{Body: []byte("plain")}
+	if err := decryptDelivery(context.Background(), mustStaticKeys(t, "a", testKeyA), &delivery); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if string(delivery.Body) != "plain" {
+		t.Fatalf("body = %q, want it untouched", delivery.Body)
+	}
+}
+
+func TestKeyProviderRejectsInvalidKeys(t *testing.T) {
+	if _, err := NewStaticKeyProvider("a", []byte("short")); err == nil {
+		t.Fatal("NewStaticKeyProvider accepted a 5-byte key")
+	}
+	if _, err := NewStaticKeyProvider("", testKeyA); err == nil {
+		t.Fatal("NewStaticKeyProvider accepted an empty key ID")
+	}
+	if _, err := NewRotatingKeyProvider("missing", map[string][]byte{"a": testKeyA}); err == nil {
+		t.Fatal("NewRotatingKeyProvider accepted a current key that is not in keys")
+	}
+	keys := mustStaticKeys(t, "a", testKeyA)
+	if err := keys.Rotate("a", testKeyB); err == nil {
+		t.Fatal("Rotate replaced an existing key with a different value")
+	}
+}
+
+func mustStaticKeys(t *testing.T, keyID string, key []byte) *RotatingKeyProvider {
+	t.Helper()
+	keys, err := NewStaticKeyProvider(keyID, key)
+	if err != nil {
+		t.Fatalf("NewStaticKeyProvider: %v", err)
+	}
+	return keys
+}
@@ -0,0 +1,93 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ErrConnectionBlocked is returned by Publish when the broker has blocked the
+// connection (memory or disk alarm) and the blocked policy does not allow waiting,
+// or the context ended while waiting.
+var ErrConnectionBlocked = errors.New("rabbitmq: connection blocked by broker")
+
+// BlockedPolicy controls how producers behave while the broker blocks the connection.
+type BlockedPolicy int
+
+const (
+	// BlockedPolicyWait makes Publish wait until the connection is unblocked or the
+	// context is done. This is the default.
+	BlockedPolicyWait BlockedPolicy = iota
+	// BlockedPolicyFail makes Publish return ErrConnectionBlocked immediately.
+	BlockedPolicyFail
+)
+
+// flowControl tracks the connection.blocked and connection.unblocked notifications
+// the broker sends when a resource alarm is raised and cleared.
+type flowControl struct {
+	policy    BlockedPolicy
+	mu        sync.Mutex
+	blocked   bool
+	reason    string
+	unblocked chan struct{}
+}
+
+// watch applies notifications until the connection closes, then releases any waiters.
+func (f *flowControl) watch(notify <-chan amqp091.Blocking, logger *slog.Logger) {
+	for b := range notify {
+		if b.Active {
+			logger.Warn("connection blocked by broker", slog.String("reason", b.Reason))
+		} else {
+			logger.Info("connection unblocked by broker")
+		}
+		f.set(b.Active, b.Reason)
+	}
+	f.set(false, "")
+}
+
+// set updates the blocked state, waking up waiters when the connection is unblocked.
+func (f *flowControl) set(blocked bool, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case blocked && !f.blocked:
+		f.unblocked = make(chan struct{})
+	case !blocked && f.blocked:
+		close(f.unblocked)
+	}
+	f.blocked, f.reason = blocked, reason
+}
+
+// isBlocked reports whether the broker is blocking the connection.
+func (f *flowControl) isBlocked() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.blocked
+}
+
+// admit applies the blocked policy before a publish. It returns nil when publishing
+// may proceed and an error wrapping ErrConnectionBlocked otherwise.
+func (f *flowControl) admit(ctx context.Context) error {
+	f.mu.Lock()
+	blocked, reason, unblocked := f.blocked, f.reason, f.unblocked
+	f.mu.Unlock()
+
+	if !blocked {
+		return nil
+	}
+	if f.policy == BlockedPolicyFail {
+		return fmt.Errorf("%w: %s", ErrConnectionBlocked, reason)
+	}
+
+	select {
+	case <-unblocked:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %s: %w", ErrConnectionBlocked, reason, ctx.Err())
+	}
+}
@@ -0,0 +1,47 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlowControlAdmit(t *testing.T) {
+	f := &flowControl{}
+	if err := f.admit(context.Background()); err != nil {
+		t.Fatalf("admit on an unblocked connection: %v", err)
+	}
+
+	f.set(true, "low on memory")
+	if !f.isBlocked() {
+		t.Fatal("connection not reported as blocked")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := f.admit(ctx); !errors.Is(err, ErrConnectionBlocked) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("admit error = %v, want ErrConnectionBlocked and the context error", err)
+	}
+
+	admitted := make(chan error, 1)
+	go func() { admitted <- f.admit(context.Background()) }()
+	f.set(false, "")
+	select {
+	case err := <-admitted:
+		if err != nil {
+			t.Fatalf("admit after unblocking: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("admit still waiting after the connection was unblocked")
+	}
+}
+
+func TestFlowControlFailPolicy(t *testing.T) {
+	f := &flowControl{policy: BlockedPolicyFail}
+	f.set(true, "low on disk")
+
+	if err := f.admit(context.Background()); !errors.Is(err, ErrConnectionBlocked) {
+		t.Fatalf("admit error = %v, want ErrConnectionBlocked", err)
+	}
+}
@@ -0,0 +1,37 @@
+package rabbitmq
+
+import (
+	"context"
+	"time"
+)
+
+// Instrumentation receives producer and consumer events so they can be exported as metrics.
+// Implementations must be safe for concurrent use and should return quickly, since they
+// are called inline on the publish and consume paths.
+//
+// Ready-made implementations for OpenTelemetry and Cloud Monitoring live in the
+// rabbitmqmetrics package.
+type Instrumentation interface {
+	// MessagePublished is called after every publish attempt; err is nil on success.
+	MessagePublished(ctx context.Context, queueName string, size int, duration time.Duration, err error)
+	// MessageReceived is called when a delivery is taken for processing.
+	MessageReceived(ctx context.Context, queueName string, size int)
+	// MessageHandled is called when the handler returns; err is nil on success. Messages
+	// that fail to decode are reported with an error wrapping ErrDecode.
+	MessageHandled(ctx context.Context, queueName string, duration time.Duration, err error)
+	// MessageAcked is called after a delivery is acknowledged.
+	MessageAcked(ctx context.Context, queueName string)
+	// MessageNacked is called after a delivery is rejected; requeue reports whether it went back to the queue.
+	MessageNacked(ctx context.Context, queueName string, requeue bool)
+	// InFlight is called with +1 when a message starts processing and -1 when it finishes.
+	InFlight(ctx context.Context, queueName string, delta int)
+}
+
+type noopInstrumentation struct{}
+
+func (noopInstrumentation) MessagePublished(context.Context, string, int, time.Duration, error) {}
+func (noopInstrumentation) MessageReceived(context.Context, string, int)                        {}
+func (noopInstrumentation) MessageHandled(context.Context, string, time.Duration, error)        {}
+func (noopInstrumentation) MessageAcked(context.Context, string)                                {}
+func (noopInstrumentation) MessageNacked(context.Context, string, bool)                         {}
+func (noopInstrumentation) InFlight(context.Context, string, int)                               {}
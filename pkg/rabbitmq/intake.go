@@ -0,0 +1,216 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// cycleEnd reports why a subscription cycle ended.
+type cycleEnd int
+
+const (
+	// cycleDone means the consumer context was canceled, Stop was called or the channel closed.
+	cycleDone cycleEnd = iota
+	// cycleInterrupted means the intake state changed and the subscription must be renewed.
+	cycleInterrupted
+	// cycleClosed means the broker closed the deliveries channel (e.g. queue deleted).
+	cycleClosed
+)
+
+// processFunc handles the deliveries of one subscription cycle. It must return when
+// msgs is closed, done is closed or interrupt is closed, after in-flight messages finish.
+type processFunc func(msgs <-chan amqp091.Delivery, done, interrupt <-chan struct{}) cycleEnd
+
+// intake tracks whether a consumer may take deliveries and signals every change of
+// that state, so the consume loop can cancel and renew its subscription.
+type intake struct {
+	mu      sync.Mutex
+	changed chan struct{}
+	paused  bool
+}
+
+// setPaused updates the paused flag and signals a change when it flips.
+func (in *intake) setPaused(paused bool) bool {
+	in.mu.Lock()
+	if in.paused == paused {
+		in.mu.Unlock()
+		return false
+	}
+	in.paused = paused
+	in.mu.Unlock()
+
+	in.notify()
+	return true
+}
+
+// isPaused reports whether intake was paused.
+func (in *intake) isPaused() bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.paused
+}
+
+// changes returns a channel that is closed on the next intake state change.
+func (in *intake) changes() <-chan struct{} {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.changed == nil {
+		in.changed = make(chan struct{})
+	}
+	return in.changed
+}
+
+// notify signals an intake state change.
+func (in *intake) notify() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.changed != nil {
+		close(in.changed)
+	}
+	in.changed = make(chan struct{})
+}
+
+// ready reports whether deliveries may be taken and, if not, how long to wait before
+// checking again (zero means wait for the next state change).
+func (c *consumer) ready() (bool, time.Duration) {
+	if c.intake.isPaused() {
+		return false, 0
+	}
+	if c.breaker != nil {
+		if ok, wait := c.breaker.allow(); !ok {
+			return false, wait
+		}
+	}
+	return true, 0
+}
+
+// prefetchFor returns the prefetch count for the next subscription cycle.
+func (c *consumer) prefetchFor(prefetch int) int {
+	if c.breaker != nil && c.breaker.current() == BreakerHalfOpen {
+		return 1
+	}
+	return prefetch
+}
+
+// recordResult feeds a handler result to the circuit breaker, if any.
+func (c *consumer) recordResult(err error) {
+	if c.breaker != nil {
+		c.breaker.record(err)
+	}
+}
+
+// onBreakerChange logs a circuit breaker transition and wakes up the consume loop.
+func (c *consumer) onBreakerChange(from, to BreakerState) {
+	c.logger.Warn("circuit breaker state changed",
+		slog.String("from", from.String()),
+		slog.String("to", to.String()))
+	c.intake.notify()
+}
+
+// run opens a channel and drives subscription cycles on it until shutdown.
+// Each cycle sets the prefetch, subscribes with tag and the arguments returned by args
+// (which may be nil) and hands the deliveries to process.
+// When a cycle is interrupted the subscription is canceled (basic.cancel), buffered
+// deliveries are requeued and the loop waits until intake is allowed again.
+func (c *consumer) run(tag string, prefetch int, args func() amqp091.Table, process processFunc) error {
+	if c.stopCtx.Err() != nil {
+		return nil
+	}
+	c.active.Add(1)
+	defer c.active.Done()
+
+	ch, closeChan, err := c.openChannel()
+	if err != nil {
+		return err
+	}
+	defer c.closeChannel(ch)
+
+	done, shutdownErr := c.watchShutdown(closeChan)
+
+	for {
+		interrupt := c.intake.changes()
+
+		if ok, wait := c.ready(); !ok {
+			if !c.waitIntake(done, interrupt, wait) {
+				return shutdownErr()
+			}
+			continue
+		}
+
+		// Set QoS to limit unacknowledged messages per consumer
+		if err := ch.Qos(c.prefetchFor(prefetch), 0, false); err != nil {
+			return fmt.Errorf("error setting QoS: %w", err)
+		}
+
+		var consumeArgs amqp091.Table
+		if args != nil {
+			consumeArgs = args()
+		}
+
+		msgs, err := ch.Consume(
+			c.queueName, // queue name
+			tag,         // consumer name
+			false,       // auto-ack (acknowledgement is handled by the handler)
+			false,       // exclusive (only this consumer can access the queue)
+			false,       // no-local (can't consume messages from this connection)
+			false,       // no-wait (don't wait for the server to confirm the request)
+			consumeArgs, // args (optional arguments)
+		)
+		if err != nil {
+			return fmt.Errorf("error consuming messages: %w", err)
+		}
+
+		switch process(msgs, done, interrupt) {
+		case cycleDone:
+			return shutdownErr()
+		case cycleClosed:
+			return nil
+		case cycleInterrupted:
+			if err := ch.Cancel(tag, false); err != nil {
+				return fmt.Errorf("error canceling consumer: %w", err)
+			}
+			c.requeueBuffered(msgs)
+		}
+	}
+}
+
+// waitIntake blocks until the intake state changes, wait elapses or done is closed.
+// It returns false when done was closed.
+func (c *consumer) waitIntake(done, interrupt <-chan struct{}, wait time.Duration) bool {
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-done:
+		return false
+	case <-interrupt:
+	case <-timeout:
+	}
+	return true
+}
+
+// requeueBuffered returns deliveries that were prefetched but not processed to the
+// queue, until the canceled subscription closes msgs. Stream deliveries are acknowledged
+// instead: the stream keeps them and the next subscription reads them again from the
+// tracked offset.
+func (c *consumer) requeueBuffered(msgs <-chan amqp091.Delivery) {
+	for msg := range msgs {
+		if c.stream {
+			if err := msg.Ack(false); err != nil {
+				c.logger.Error("failed to release buffered message", slog.String("error", err.Error()))
+			}
+			continue
+		}
+		if err := msg.Nack(false, true); err != nil {
+			c.logger.Error("failed to requeue buffered message", slog.String("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ClientOption configures a Client created by NewClient.
+// Producers and consumers created from the client inherit its settings.
+type ClientOption func(*client)
+
+// WithTracerProvider sets the OpenTelemetry tracer provider used to create
+// producer and consumer spans. Defaults to the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) ClientOption {
+	return func(c *client) {
+		if tp != nil {
+			c.tracer = tp.Tracer(tracerName)
+		}
+	}
+}
+
+// WithPropagator sets the propagator used to inject and extract trace context
+// into AMQP message headers. Defaults to W3C Trace Context (traceparent/tracestate).
+func WithPropagator(p propagation.TextMapPropagator) ClientOption {
+	return func(c *client) {
+		if p != nil {
+			c.propagator = p
+		}
+	}
+}
+
+// WithInstrumentation sets the instrumentation notified by producers and consumers.
+// Defaults to a no-op implementation.
+func WithInstrumentation(i Instrumentation) ClientOption {
+	return func(c *client) {
+		if i != nil {
+			c.instrumentation = i
+		}
+	}
+}
+
+// WithLogger sets the logger used by the client and the consumers it creates.
+// Defaults to slog.Default(). Use slog.New(slog.DiscardHandler) to silence logging.
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *client) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// WithBlockedPolicy sets how producers behave while the broker blocks the connection
+// because of a resource alarm. Defaults to BlockedPolicyWait.
+//
+// The policy is applied before each publish, once the client has received the
+// connection.blocked notification. The AMQP client does not interrupt a broker call
+// when its context is done, so a publish already writing to the connection when it is
+// blocked, including the queue declaration that precedes it, waits until the broker
+// unblocks the connection or it closes, whatever the context. Publishes waiting for
+// that producer meanwhile still honor their context.
+func WithBlockedPolicy(policy BlockedPolicy) ClientOption {
+	return func(c *client) {
+		c.flow.policy = policy
+	}
+}
+
+// WithCompression compresses the body of published messages of at least threshold
+// bytes with algorithm, setting the AMQP content-encoding property. Bodies that do not
+// shrink are sent uncompressed. Consumers decompress gzip and zstd bodies transparently,
+// whatever this option, so it can be enabled after every consumer is upgraded.
+// NewClient fails when algorithm is neither CompressionGzip nor CompressionZstd.
+func WithCompression(algorithm CompressionAlgorithm, threshold int) ClientOption {
+	return func(c *client) {
+		if algorithm != CompressionGzip && algorithm != CompressionZstd {
+			c.optErr = errors.Join(c.optErr, fmt.Errorf("unsupported compression algorithm %q", algorithm))
+			return
+		}
+		c.compression = &compression{algorithm: algorithm, threshold: threshold}
+	}
+}
+
+// WithEncryption encrypts the body of published messages with AES-GCM envelope
+// encryption: each message gets a random data key, wrapped with the current key of
+// keys and sent in the message headers along with its key ID. Consumers decrypt
+// messages before decompressing them and invoking the handler; messages whose key is
+// unknown to keys are dead-lettered.
+func WithEncryption(keys KeyProvider) ClientOption {
+	return func(c *client) {
+		c.keys = keys
+	}
+}
+
+// WithClaimCheck offloads the body of published messages of at least cfg.Threshold
+// bytes to cfg.Store, publishing a reference in the x-claim-check header instead.
+// Consumers fetch the payload before invoking the handler; messages whose payload is
+// missing from the store are dead-lettered.
+func WithClaimCheck(cfg ClaimCheckCfg) ClientOption {
+	return func(c *client) {
+		c.claims = newClaimCheck(cfg)
+	}
+}
+
+// WithDelayedDelivery configures how producers publish messages delayed with WithDelay
+// or WithDeliverAt. Without it, delayed messages use DelayStrategyTTL with default names.
+func WithDelayedDelivery(cfg DelayCfg) ClientOption {
+	return func(c *client) {
+		c.delays = newDelayer(cfg)
+	}
+}
+
+// WithChannelCloseHandler sets a function called when a producer channel is closed by
+// the broker or the network with an error. The close is also logged; the producer
+// reopens its channel on the next publish.
+func WithChannelCloseHandler(fn func(err error)) ClientOption {
+	return func(c *client) {
+		c.onChannelClose = fn
+	}
+}
+
+// WithSchemaValidation validates message bodies against the schemas in cfg.Registry.
+// Producers reject invalid messages before publishing them; consumers dead-letter them
+// without invoking the handler. See SchemaCfg.
+func WithSchemaValidation(cfg SchemaCfg) ClientOption {
+	return func(c *client) {
+		c.schemas = newSchemaValidator(cfg)
+	}
+}
+
+// ConsumerOption configures a Consumer created by NewConsumer.
+type ConsumerOption func(*consumer)
+
+// WithConsumerLogger overrides the client logger for a single consumer.
+func WithConsumerLogger(l *slog.Logger) ConsumerOption {
+	return func(c *consumer) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// WithLogAttrs attaches attributes to every log record emitted by the consumer.
+// Arguments follow the slog.Logger.With convention (key-value pairs or slog.Attr).
+func WithLogAttrs(args ...any) ConsumerOption {
+	return func(c *consumer) {
+		c.logAttrs = append(c.logAttrs, args...)
+	}
+}
+
+// WithErrorLogLevel sets the function that chooses the level used to log handler errors.
+// It lets expected failures (e.g. validation errors) be logged below Error.
+// By default every handler error is logged at slog.LevelError.
+func WithErrorLogLevel(fn func(err error) slog.Level) ConsumerOption {
+	return func(c *consumer) {
+		if fn != nil {
+			c.errorLevel = fn
+		}
+	}
+}
+
+// WithRateLimiter limits the rate at which the consumer takes deliveries for processing.
+// The same limiter may be shared by several consumers. See NewRateLimiter.
+func WithRateLimiter(l RateLimiter) ConsumerOption {
+	return func(c *consumer) {
+		c.rateLimiter = l
+	}
+}
+
+// WithCircuitBreaker stops consumption while the handler keeps failing.
+// See CircuitBreakerCfg for the state machine and defaults.
+func WithCircuitBreaker(cfg CircuitBreakerCfg) ConsumerOption {
+	return func(c *consumer) {
+		c.breakerCfg = &cfg
+	}
+}
+
+func defaultErrorLevel(error) slog.Level {
+	return slog.LevelError
+}
+
+// PublishOption customizes a single published message.
+type PublishOption func(*amqp091.Publishing)
+
+// WithPriority sets the message priority. It only has an effect on queues declared
+// with DeclarePriorityQueue; priorities above the queue maximum are treated as the maximum.
+func WithPriority(priority uint8) PublishOption {
+	return func(msg *amqp091.Publishing) {
+		msg.Priority = priority
+	}
+}
+
+// WithType sets the AMQP type property of the message, which a Dispatcher uses by
+// default to route it.
+func WithType(msgType string) PublishOption {
+	return func(msg *amqp091.Publishing) {
+		msg.Type = msgType
+	}
+}
+
+// WithSchemaVersion sets the schema version of the message, validated by
+// WithSchemaValidation. Without it, the SchemaCfg default version is used.
+func WithSchemaVersion(version string) PublishOption {
+	return func(msg *amqp091.Publishing) {
+		if msg.Headers == nil {
+			msg.Headers = amqp091.Table{}
+		}
+		msg.Headers[headerSchemaVersion] = version
+	}
+}
+
+// WithDelay makes the message available to consumers only after d, following the
+// client DelayCfg. Zero or negative delays publish the message immediately.
+func WithDelay(d time.Duration) PublishOption {
+	return func(msg *amqp091.Publishing) {
+		if msg.Headers == nil {
+			msg.Headers = amqp091.Table{}
+		}
+		msg.Headers[headerDelay] = d.Milliseconds()
+	}
+}
+
+// WithDeliverAt makes the message available to consumers at t. See WithDelay.
+func WithDeliverAt(t time.Time) PublishOption {
+	return func(msg *amqp091.Publishing) {
+		WithDelay(time.Until(t))(msg)
+	}
+}
+
+// RouterOption configures a Router created by NewRouter.
+type RouterOption func(*Router)
+
+// WithRouterLogger sets the logger used by the router. Defaults to slog.Default().
+func WithRouterLogger(l *slog.Logger) RouterOption {
+	return func(r *Router) {
+		if l != nil {
+			r.logger = l
+		}
+	}
+}
+
+// WithRestartBackoff sets the delay before restarting a consumer that exited, doubled
+// after each consecutive restart up to maxDelay. Defaults to 1s and 30s.
+func WithRestartBackoff(delay, maxDelay time.Duration) RouterOption {
+	return func(r *Router) {
+		if delay > 0 {
+			r.restartDelay = delay
+		}
+		if maxDelay >= r.restartDelay {
+			r.maxRestartDelay = maxDelay
+		}
+	}
+}
+
+// WithShutdownTimeout sets how long Run waits for consumers to finish their in-flight
+// messages once its context is canceled. Defaults to 30s.
+func WithShutdownTimeout(d time.Duration) RouterOption {
+	return func(r *Router) {
+		if d > 0 {
+			r.shutdownTimeout = d
+		}
+	}
+}
+
+// DispatcherOption configures a Dispatcher created by NewDispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithTypeHeader makes the dispatcher read the message type from the string header key
+// instead of the AMQP type property.
+func WithTypeHeader(key string) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.typeOf = headerType(key)
+	}
+}
+
+// WithTypeField makes the dispatcher read the message type from a string field of the
+// JSON body instead of the AMQP type property. Nested fields are separated by dots,
+// e.g. "meta.eventType".
+func WithTypeField(path string) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.typeOf = fieldType(path)
+	}
+}
+
+// WithUnknownTypePolicy sets what the dispatcher does with messages whose type has no
+// handler. Defaults to UnknownTypePolicyDeadLetter.
+func WithUnknownTypePolicy(policy UnknownTypePolicy) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.unknown = policy
+	}
+}
@@ -0,0 +1,131 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrNotFound is returned by queue management operations when the queue or
+	// exchange does not exist (AMQP 404).
+	ErrNotFound = errors.New("rabbitmq: not found")
+	// ErrInUse is returned by queue management operations when the queue or exchange
+	// has consumers or bindings, is not empty, or is locked by another connection
+	// (AMQP 405 and 406).
+	ErrInUse = errors.New("rabbitmq: in use")
+)
+
+// QueueInfo describes the current state of a queue.
+type QueueInfo struct {
+	// Name of the queue.
+	Name string
+	// Messages is the number of messages ready for delivery, excluding unacknowledged ones.
+	Messages int
+	// Consumers is the number of active consumers.
+	Consumers int
+}
+
+// InspectQueue returns the message and consumer counts of an existing queue, without
+// declaring it (passive declare). It returns ErrNotFound if the queue does not exist.
+func (k *client) InspectQueue(name string) (QueueInfo, error) {
+	var info QueueInfo
+	err := k.withChannel(func(ch *amqp091.Channel) error {
+		q, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+		if err != nil {
+			return err
+		}
+		info = QueueInfo{Name: q.Name, Messages: q.Messages, Consumers: q.Consumers}
+		return nil
+	})
+	if err != nil {
+		return QueueInfo{}, adminError("inspect queue", name, err)
+	}
+	return info, nil
+}
+
+// PurgeQueue removes all messages ready for delivery from a queue and returns how many
+// were removed. Unacknowledged messages are not affected.
+func (k *client) PurgeQueue(name string) (int, error) {
+	var purged int
+	err := k.withChannel(func(ch *amqp091.Channel) error {
+		var err error
+		purged, err = ch.QueuePurge(name, false)
+		return err
+	})
+	if err != nil {
+		return 0, adminError("purge queue", name, err)
+	}
+	return purged, nil
+}
+
+// DeleteQueue deletes a queue and returns the number of messages it held.
+// With ifUnused the queue is only deleted if it has no consumers, and with ifEmpty only
+// if it has no messages; otherwise ErrInUse is returned.
+func (k *client) DeleteQueue(name string, ifUnused, ifEmpty bool) (int, error) {
+	var deleted int
+	err := k.withChannel(func(ch *amqp091.Channel) error {
+		var err error
+		deleted, err = ch.QueueDelete(name, ifUnused, ifEmpty, false)
+		return err
+	})
+	if err != nil {
+		return 0, adminError("delete queue", name, err)
+	}
+	k.queues.remove(name)
+	return deleted, nil
+}
+
+// UnbindQueue removes the binding of a queue to an exchange with the given routing key.
+func (k *client) UnbindQueue(name, exchange, routingKey string) error {
+	err := k.withChannel(func(ch *amqp091.Channel) error {
+		return ch.QueueUnbind(name, routingKey, exchange, nil)
+	})
+	if err != nil {
+		return adminError("unbind queue", name, err)
+	}
+	return nil
+}
+
+// DeleteExchange deletes an exchange. With ifUnused the exchange is only deleted if it
+// has no bindings; otherwise ErrInUse is returned.
+func (k *client) DeleteExchange(name string, ifUnused bool) error {
+	err := k.withChannel(func(ch *amqp091.Channel) error {
+		return ch.ExchangeDelete(name, ifUnused, false)
+	})
+	if err != nil {
+		return adminError("delete exchange", name, err)
+	}
+	return nil
+}
+
+// withChannel runs fn on a short-lived channel. A failed operation closes the channel
+// on the broker side, so management operations never share the producer or consumer ones.
+func (k *client) withChannel(fn func(ch *amqp091.Channel) error) error {
+	ch, err := k.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("error opening channel: %w", err)
+	}
+
+	err = fn(ch)
+	if closeErr := ch.Close(); closeErr != nil && err == nil {
+		k.logger.Error("error closing channel", slog.String("error", closeErr.Error()))
+	}
+	return err
+}
+
+// adminError wraps err with ErrNotFound or ErrInUse according to the AMQP reply code.
+func adminError(op, name string, err error) error {
+	var amqpErr *amqp091.Error
+	if errors.As(err, &amqpErr) {
+		switch amqpErr.Code {
+		case amqp091.NotFound:
+			return fmt.Errorf("%s %q: %w: %w", op, name, ErrNotFound, err)
+		case amqp091.ResourceLocked, amqp091.PreconditionFailed:
+			return fmt.Errorf("%s %q: %w: %w", op, name, ErrInUse, err)
+		}
+	}
+	return fmt.Errorf("%s %q: %w", op, name, err)
+}
@@ -0,0 +1,302 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchHandler processes a group of message payloads at once.
+type BatchHandler interface {
+	// HandleBatch processes the bodies of a batch.
+	// Returning nil acknowledges the whole batch, a *BatchError nacks only the
+	// listed messages and any other error nacks and requeues the whole batch.
+	// Errors wrapping ErrDeadLetter nack without requeue.
+	HandleBatch(bodies [][]byte) error
+}
+
+// BatchError is returned by a BatchHandler to report that only part of a batch failed.
+// Messages at Indexes are nacked and requeued, or dead-lettered when Err wraps
+// ErrDeadLetter; the others are acknowledged.
+type BatchError struct {
+	Indexes []int
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%d messages in batch failed", len(e.Indexes))
+	}
+	return fmt.Sprintf("%d messages in batch failed: %s", len(e.Indexes), e.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+type batchConsumer struct {
+	*consumer
+	batchHandler BatchHandler
+	batchSize    int
+	maxWait      time.Duration
+}
+
+// NewBatchConsumer creates a consumer that delivers messages to handler in batches of
+// up to batchSize messages, or fewer when maxWait elapses after the first message of a batch.
+// The prefetch count of each channel is set to batchSize.
+func (k *client) NewBatchConsumer(consumerName, queueName string, handler BatchHandler, batchSize int, maxWait time.Duration, opts ...ConsumerOption) (Consumer, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("handler cannot be nil")
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be greater than 0")
+	}
+	if maxWait <= 0 {
+		return nil, fmt.Errorf("max wait must be greater than 0")
+	}
+
+	return &batchConsumer{
+		consumer:     k.newConsumer(consumerName, queueName, nil, opts),
+		batchHandler: handler,
+		batchSize:    batchSize,
+		maxWait:      maxWait,
+	}, nil
+}
+
+// Consume starts consuming batches. Each unit of concurrency runs on its own channel
+// and processes one batch at a time, so a successful batch is acknowledged with a
+// single multiple=true ack.
+func (b *batchConsumer) Consume(concurrency int) error {
+	if concurrency <= 0 {
+		return fmt.Errorf("concurrency must be greater than 0")
+	}
+
+	b.logger.Info("batch consumer started",
+		slog.Int("concurrency", concurrency),
+		slog.Int("batchSize", b.batchSize),
+		slog.Duration("maxWait", b.maxWait))
+
+	errs := make([]error, concurrency)
+	wg := sync.WaitGroup{}
+	for i := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = b.consumeChannel(b.name + "-" + strconv.Itoa(i))
+		}()
+	}
+	wg.Wait()
+
+	b.logger.Info("batch consumer stopped")
+	return errors.Join(errs...)
+}
+
+// consumeChannel runs the batch loop on a dedicated channel until shutdown.
+func (b *batchConsumer) consumeChannel(tag string) error {
+	return b.run(tag, b.batchSize, nil, func(msgs <-chan amqp091.Delivery, done, interrupt <-chan struct{}) cycleEnd {
+		batch := make([]amqp091.Delivery, 0, b.batchSize)
+		timer := time.NewTimer(b.maxWait)
+		timer.Stop()
+		defer timer.Stop()
+
+		flush := func() {
+			if len(batch) > 0 {
+				b.handleBatch(batch)
+				batch = batch[:0]
+			}
+			timer.Stop()
+		}
+
+		for {
+			select {
+			case <-done:
+				flush()
+				return cycleDone
+
+			case <-interrupt:
+				flush()
+				return cycleInterrupted
+
+			case <-timer.C:
+				flush()
+
+			case msg, ok := <-msgs:
+				if !ok {
+					flush()
+					return cycleClosed
+				}
+
+				if len(msg.Body) == 0 {
+					if err := msg.Ack(false); err != nil {
+						b.logger.Error("failed to ack empty message", slog.String("error", err.Error()))
+					}
+					continue
+				}
+
+				if !b.decodeBatchDelivery(&msg) {
+					continue
+				}
+
+				if err := b.waitRateLimit(done, interrupt); err != nil {
+					b.nack(b.context, &msg, true)
+					continue
+				}
+
+				if len(batch) == 0 {
+					timer.Reset(b.maxWait)
+				}
+				batch = append(batch, msg)
+				if len(batch) == b.batchSize {
+					flush()
+				}
+			}
+		}
+	})
+}
+
+// decodeBatchDelivery decodes msg before it joins a batch. A message that fails to
+// decode is reported as processDelivery does, received and handled with the error in its
+// own process span, then nacked; it returns false for it.
+func (b *batchConsumer) decodeBatchDelivery(msg *amqp091.Delivery) bool {
+	start, size := time.Now(), len(msg.Body)
+	err := b.decodeDelivery(b.context, msg)
+	if err == nil {
+		return true
+	}
+
+	ctx, span := startProcessSpan(b.context, b.tracer, b.propagator, b.queueName, b.name, msg, trace.WithTimestamp(start))
+	defer span.End()
+	span.SetAttributes(semconv.MessagingMessageBodySize(size))
+
+	b.instrumentation.MessageReceived(ctx, b.queueName, size)
+	b.instrumentation.MessageHandled(ctx, b.queueName, time.Since(start), err)
+	recordSpanError(span, err)
+	b.logger.Error("error decoding message", slog.String("error", err.Error()))
+	b.nack(ctx, msg, !errors.Is(err, ErrDeadLetter))
+	return false
+}
+
+// handleBatch invokes the batch handler and settles every delivery of the batch.
+func (b *batchConsumer) handleBatch(batch []amqp091.Delivery) {
+	ctx, span := b.startBatchSpan(batch)
+	defer span.End()
+
+	bodies := make([][]byte, len(batch))
+	for i := range batch {
+		bodies[i] = batch[i].Body
+		b.instrumentation.MessageReceived(ctx, b.queueName, len(batch[i].Body))
+	}
+	b.instrumentation.InFlight(ctx, b.queueName, len(batch))
+	defer b.instrumentation.InFlight(ctx, b.queueName, -len(batch))
+
+	start := time.Now()
+	err := b.invokeBatchHandler(bodies)
+	duration := time.Since(start)
+	recordSpanError(span, err)
+	// Before settling, so a half-open breaker decides before the next probe is delivered
+	b.recordResult(err)
+	requeue := !errors.Is(err, ErrDeadLetter)
+
+	var batchErr *BatchError
+	switch {
+	case err == nil:
+		for range batch {
+			b.instrumentation.MessageHandled(ctx, b.queueName, duration, nil)
+		}
+		b.logger.Debug("batch handled successfully", slog.Int("size", len(batch)))
+		last := batch[len(batch)-1]
+		if err := last.Ack(true); err != nil {
+			b.logger.Error("failed to ack batch", slog.String("error", err.Error()))
+			return
+		}
+		for i := range batch {
+			b.instrumentation.MessageAcked(ctx, b.queueName)
+			b.claims.release(ctx, b.logger, batch[i].Headers)
+		}
+
+	case errors.As(err, &batchErr):
+		b.logger.Log(ctx, b.errorLevel(err), "error handling part of batch",
+			slog.String("error", err.Error()),
+			slog.Int("size", len(batch)),
+			slog.Int("failed", len(batchErr.Indexes)))
+		failed := make(map[int]bool, len(batchErr.Indexes))
+		for _, i := range batchErr.Indexes {
+			failed[i] = true
+		}
+		for i := range batch {
+			if failed[i] {
+				b.instrumentation.MessageHandled(ctx, b.queueName, duration, err)
+				b.nack(ctx, &batch[i], requeue)
+				continue
+			}
+			b.instrumentation.MessageHandled(ctx, b.queueName, duration, nil)
+			if b.ack(ctx, &batch[i]) {
+				b.claims.release(ctx, b.logger, batch[i].Headers)
+			}
+		}
+
+	default:
+		for range batch {
+			b.instrumentation.MessageHandled(ctx, b.queueName, duration, err)
+		}
+		b.logger.Log(ctx, b.errorLevel(err), "error handling batch",
+			slog.String("error", err.Error()),
+			slog.Int("size", len(batch)))
+		last := batch[len(batch)-1]
+		if err := last.Nack(true, requeue); err != nil {
+			b.logger.Error("failed to nack batch", slog.String("error", err.Error()))
+			return
+		}
+		for range batch {
+			b.instrumentation.MessageNacked(ctx, b.queueName, requeue)
+		}
+	}
+}
+
+// invokeBatchHandler calls the batch handler, converting panics into errors.
+func (b *batchConsumer) invokeBatchHandler(bodies [][]byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("panic recovered in batch handler", slog.Any("panic", r))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return b.batchHandler.HandleBatch(bodies)
+}
+
+// startBatchSpan starts a consumer span for the batch, linked to the span of every message.
+func (b *batchConsumer) startBatchSpan(batch []amqp091.Delivery) (context.Context, trace.Span) {
+	links := make([]trace.Link, 0, len(batch))
+	for i := range batch {
+		if batch[i].Headers == nil {
+			continue
+		}
+		msgCtx := b.propagator.Extract(b.context, headerCarrier(batch[i].Headers))
+		if sc := trace.SpanContextFromContext(msgCtx); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+
+	return b.tracer.Start(b.context, "process "+b.queueName,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitMQ,
+			semconv.MessagingDestinationName(b.queueName),
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingOperationName("process"),
+			semconv.MessagingConsumerGroupName(b.name),
+			semconv.MessagingBatchMessageCount(len(batch)),
+		),
+	)
+}
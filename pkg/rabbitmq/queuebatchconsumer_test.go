@@ -0,0 +1,159 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// batchHandlerFunc adapts a function to BatchHandler.
+type batchHandlerFunc func([][]byte) error
+
+func (f batchHandlerFunc) HandleBatch(bodies [][]byte) error {
+	return f(bodies)
+}
+
+// newTestBatchConsumer returns a batch consumer reporting to a testInstrumentation.
+func newTestBatchConsumer(t *testing.T, handler BatchHandler) (*batchConsumer, *testInstrumentation) {
+	t.Helper()
+	inst := &testInstrumentation{}
+	return batchConsumerOf(t, newTestClient(WithInstrumentation(inst)), handler), inst
+}
+
+func batchConsumerOf(t *testing.T, c *client, handler BatchHandler) *batchConsumer {
+	t.Helper()
+	consumer, err := c.NewBatchConsumer("worker", "orders", handler, 10, time.Second)
+	if err != nil {
+		t.Fatalf("NewBatchConsumer: %v", err)
+	}
+	return consumer.(*batchConsumer)
+}
+
+func TestHandleBatchAcksWholeBatchAtOnce(t *testing.T) {
+	var got [][]byte
+	b, inst := newTestBatchConsumer(t, batchHandlerFunc(func(bodies [][]byte) error {
+		got = bodies
+		return nil
+	}))
+	ack := &testAcknowledger{}
+
+	b.handleBatch(ack.deliveries("a", "b", "c"))
+
+	if len(got) != 3 || string(got[2]) != "c" {
+		t.Fatalf("handler got %q, want the three bodies in order", got)
+	}
+	want := []settlement{{tag: 3, ack: true, multiple: true}}
+	if fmt.Sprint(ack.settled) != fmt.Sprint(want) {
+		t.Fatalf("settled = %+v, want a single multiple ack of the last tag", ack.settled)
+	}
+	if inst.received != 3 || inst.acked != 3 || len(inst.handled) != 3 {
+		t.Fatalf("instrumentation = %d received, %d handled, %d acked, want 3 each", inst.received, len(inst.handled), inst.acked)
+	}
+}
+
+func TestHandleBatchSettlesPartialFailures(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		err     error
+		requeue bool
+	}{
+		{name: "requeue", err: errors.New("timeout"), requeue: true},
+		{name: "dead letter", err: fmt.Errorf("invalid: %w", ErrDeadLetter), requeue: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, inst := newTestBatchConsumer(t, batchHandlerFunc(func([][]byte) error {
+				return &BatchError{Indexes: []int{0, 2}, Err: tc.err}
+			}))
+			ack := &testAcknowledger{}
+
+			b.handleBatch(ack.deliveries("a", "b", "c", "d"))
+
+			want := []settlement{
+				{tag: 1, requeue: tc.requeue},
+				{tag: 2, ack: true},
+				{tag: 3, requeue: tc.requeue},
+				{tag: 4, ack: true},
+			}
+			if fmt.Sprint(ack.settled) != fmt.Sprint(want) {
+				t.Fatalf("settled = %+v, want %+v", ack.settled, want)
+			}
+			if inst.acked != 2 || fmt.Sprint(inst.nacked) != fmt.Sprint([]bool{tc.requeue, tc.requeue}) {
+				t.Fatalf("instrumentation = %d acked, nacked %v", inst.acked, inst.nacked)
+			}
+		})
+	}
+}
+
+func TestHandleBatchNacksWholeBatchOnError(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		err     error
+		requeue bool
+	}{
+		{name: "requeue", err: errors.New("database down"), requeue: true},
+		{name: "dead letter", err: fmt.Errorf("poison batch: %w", ErrDeadLetter), requeue: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, inst := newTestBatchConsumer(t, batchHandlerFunc(func([][]byte) error { return tc.err }))
+			ack := &testAcknowledger{}
+
+			b.handleBatch(ack.deliveries("a", "b"))
+
+			want := []settlement{{tag: 2, multiple: true, requeue: tc.requeue}}
+			if fmt.Sprint(ack.settled) != fmt.Sprint(want) {
+				t.Fatalf("settled = %+v, want %+v", ack.settled, want)
+			}
+			if len(inst.handled) != 2 {
+				t.Fatalf("handled reported %d times, want once per message", len(inst.handled))
+			}
+		})
+	}
+}
+
+func TestHandleBatchRecoversPanics(t *testing.T) {
+	b, _ := newTestBatchConsumer(t, batchHandlerFunc(func([][]byte) error { panic("boom") }))
+	ack := &testAcknowledger{}
+
+	b.handleBatch(ack.deliveries("a"))
+
+	if want := []settlement{{tag: 1, multiple: true, requeue: true}}; fmt.Sprint(ack.settled) != fmt.Sprint(want) {
+		t.Fatalf("settled = %+v, want %+v", ack.settled, want)
+	}
+}
+
+func TestBatchDecodeFailureIsReported(t *testing.T) {
+	inst := &testInstrumentation{}
+	c, recorder := newRecordingClient(t, WithInstrumentation(inst))
+	b := batchConsumerOf(t, c, batchHandlerFunc(func([][]byte) error { return nil }))
+	ack := &testAcknowledger{}
+
+	// Encrypted, but the consumer has no key provider: undecodable forever.
+	msg := ack.deliveries("ciphertext")[0]
+	msg.Headers = amqp091.Table{headerEncryptionKeyID: "k1"}
+	if b.decodeBatchDelivery(&msg) {
+		t.Fatal("undecodable message accepted into the batch")
+	}
+
+	if want := []settlement{{tag: 1}}; fmt.Sprint(ack.settled) != fmt.Sprint(want) {
+		t.Fatalf("settled = %+v, want a nack without requeue", ack.settled)
+	}
+	if inst.received != 1 || len(inst.handled) != 1 || !errors.Is(inst.handled[0], ErrDecode) {
+		t.Fatalf("instrumentation = %d received, handled %v, want one of each wrapping ErrDecode", inst.received, inst.handled)
+	}
+	if fmt.Sprint(inst.nacked) != "[false]" {
+		t.Fatalf("nacked = %v, want [false]", inst.nacked)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Status().Code != codes.Error {
+		t.Fatalf("ended %d spans, want one failed process span", len(spans))
+	}
+
+	good := ack.deliveries("plain")[0]
+	if !b.decodeBatchDelivery(&good) || len(recorder.Ended()) != 1 {
+		t.Fatal("decodable message rejected or traced on its own")
+	}
+}
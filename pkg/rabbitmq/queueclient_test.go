@@ -0,0 +1,105 @@
+package rabbitmq
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// newTestClient returns a client without a connection, for exercising the code paths
+// that do not talk to the broker.
+func newTestClient(opts ...ClientOption) *client {
+	c, err := newClient(context.Background(), append([]ClientOption{WithLogger(slog.New(slog.DiscardHandler))}, opts...))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// testHandler adapts a function to ConsumerHandler.
+type testHandler func([]byte) error
+
+func (f testHandler) HandleMessage(body []byte) error {
+	return f(body)
+}
+
+// settlement records how a delivery was acknowledged or rejected.
+type settlement struct {
+	tag      uint64
+	ack      bool
+	multiple bool
+	requeue  bool
+}
+
+// testAcknowledger records the settlements of the deliveries it is attached to.
+type testAcknowledger struct {
+	mu      sync.Mutex
+	settled []settlement
+}
+
+func (a *testAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.record(settlement{tag: tag, ack: true, multiple: multiple})
+	return nil
+}
+
+func (a *testAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.record(settlement{tag: tag, multiple: multiple, requeue: requeue})
+	return nil
+}
+
+func (a *testAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.record(settlement{tag: tag, requeue: requeue})
+	return nil
+}
+
+func (a *testAcknowledger) record(s settlement) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.settled = append(a.settled, s)
+}
+
+// deliveries returns one delivery per body, with delivery tags starting at 1.
+func (a *testAcknowledger) deliveries(bodies ...string) []amqp091.Delivery {
+	msgs := make([]amqp091.Delivery, len(bodies))
+	for i, body := range bodies {
+		msgs[i] = amqp091.Delivery{Acknowledger: a, DeliveryTag: uint64(i + 1), Body: []byte(body)}
+	}
+	return msgs
+}
+
+// testInstrumentation records the consumer events it receives.
+type testInstrumentation struct {
+	noopInstrumentation
+	mu       sync.Mutex
+	received int
+	handled  []error
+	acked    int
+	nacked   []bool
+}
+
+func (i *testInstrumentation) MessageReceived(context.Context, string, int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.received++
+}
+
+func (i *testInstrumentation) MessageHandled(_ context.Context, _ string, _ time.Duration, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handled = append(i.handled, err)
+}
+
+func (i *testInstrumentation) MessageAcked(context.Context, string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.acked++
+}
+
+func (i *testInstrumentation) MessageNacked(_ context.Context, _ string, requeue bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.nacked = append(i.nacked, requeue)
+}
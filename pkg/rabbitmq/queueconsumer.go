@@ -2,168 +2,351 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Consumer consumes messages from a RabbitMQ queue.
 type Consumer interface {
+	// Consume starts consuming messages with the given concurrency and blocks until
+	// the client context is canceled, Stop is called or the channel closes.
 	Consume(concurrency int) error
+	// Pause stops taking new deliveries: the subscription is canceled, prefetched
+	// messages are requeued and messages already being processed finish normally.
+	Pause()
+	// Resume restarts consumption after Pause.
+	Resume()
+	// IsPaused reports whether the consumer is paused.
+	IsPaused() bool
+	// Stop shuts the consumer down and waits for in-flight messages to finish,
+	// or until ctx is done. A stopped consumer cannot be started again.
+	Stop(ctx context.Context) error
 }
 
 type consumer struct {
-	name      string
-	queueName string
-	conn      *amqp091.Connection
-	handler   ConsumerHandler
-	context   context.Context
+	name            string
+	queueName       string
+	conn            *amqp091.Connection
+	handler         ConsumerHandler
+	context         context.Context
+	tracer          trace.Tracer
+	propagator      propagation.TextMapPropagator
+	instrumentation Instrumentation
+	logger          *slog.Logger
+	logAttrs        []any
+	errorLevel      func(error) slog.Level
+	rateLimiter     RateLimiter
+	breakerCfg      *CircuitBreakerCfg
+	breaker         *circuitBreaker
+	intake          intake
+	stopCtx         context.Context
+	stop            context.CancelFunc
+	active          sync.WaitGroup
+	queues          *queueRegistry
+	passiveDeclare  bool
+	stream          bool
+	keys            KeyProvider
+	claims          *claimCheck
+	schemas         *schemaValidator
 }
 
 // NewConsumer creates a new queue consumer bound to the provided queue and handler.
-func (k *client) NewConsumer(consumerName, queueName string, handler ConsumerHandler) (Consumer, error) {
-	return &consumer{
-		name:      consumerName,
-		queueName: queueName,
-		conn:      k.conn,
-		handler:   handler,
-		context:   k.context,
-	}, nil
+func (k *client) NewConsumer(consumerName, queueName string, handler ConsumerHandler, opts ...ConsumerOption) (Consumer, error) {
+	return k.newConsumer(consumerName, queueName, handler, opts), nil
+}
+
+// newConsumer builds a consumer with the client defaults and the given options applied.
+func (k *client) newConsumer(consumerName, queueName string, handler ConsumerHandler, opts []ConsumerOption) *consumer {
+	c := &consumer{
+		name:            consumerName,
+		queueName:       queueName,
+		conn:            k.conn,
+		handler:         handler,
+		context:         k.context,
+		tracer:          k.tracer,
+		propagator:      k.propagator,
+		instrumentation: k.instrumentation,
+		logger:          k.logger,
+		errorLevel:      defaultErrorLevel,
+		queues:          k.queues,
+		keys:            k.keys,
+		claims:          k.claims,
+		schemas:         k.schemas,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.logger = c.logger.With(slog.String("handler", c.name)).With(c.logAttrs...)
+	c.stopCtx, c.stop = context.WithCancel(c.context)
+	if c.breakerCfg != nil {
+		c.breaker = newCircuitBreaker(c.name, *c.breakerCfg, c.onBreakerChange)
+	}
+
+	return c
 }
 
 // Consume starts consuming messages with a given concurrency level.
 func (c *consumer) Consume(concurrency int) error {
-	ch, err := c.conn.Channel()
-	if err != nil {
-		return fmt.Errorf("error opening channel: %w", err)
+	if concurrency <= 0 {
+		return fmt.Errorf("concurrency must be greater than 0")
 	}
 
-	closeChan := make(chan *amqp091.Error, 1)
-	ch.NotifyClose(closeChan)
+	c.logger.Info("consumer started", slog.Int("concurrency", concurrency))
 
-	defer func() {
-		if err := ch.Close(); err != nil {
-			slog.Error("error closing channel", slog.String("error", err.Error()), slog.String("handler", c.name))
+	wg := sync.WaitGroup{}
+	semaphore := make(chan struct{}, concurrency)
+
+	err := c.run(c.name, concurrency, nil, func(msgs <-chan amqp091.Delivery, done, interrupt <-chan struct{}) cycleEnd {
+		// Message processing loop
+		for {
+			select {
+			case <-done:
+				c.logger.Info("stopping consumer, waiting for in-flight messages")
+				wg.Wait()
+				return cycleDone
+
+			case <-interrupt:
+				wg.Wait()
+				return cycleInterrupted
+
+			case msg, ok := <-msgs:
+				if !ok {
+					// Channel closed
+					c.logger.Info("messages channel closed")
+					wg.Wait()
+					return cycleClosed
+				}
+
+				if len(msg.Body) == 0 {
+					if err := msg.Ack(false); err != nil {
+						c.logger.Error("failed to ack empty message", slog.String("error", err.Error()))
+					}
+					continue
+				}
+
+				// Block intake until the rate limiter allows another message
+				if err := c.waitRateLimit(done, interrupt); err != nil {
+					c.nack(c.context, &msg, true)
+					continue
+				}
+
+				semaphore <- struct{}{}
+				wg.Add(1)
+				go c.HandleMessage(msg, &wg, semaphore)
+			}
 		}
+	})
+
+	c.logger.Info("consumer stopped")
+	return err
+}
+
+// Pause stops taking new deliveries until Resume is called.
+func (c *consumer) Pause() {
+	if c.intake.setPaused(true) {
+		c.logger.Info("consumer paused")
+	}
+}
+
+// Resume restarts consumption after Pause.
+func (c *consumer) Resume() {
+	if c.intake.setPaused(false) {
+		c.logger.Info("consumer resumed")
+	}
+}
+
+// IsPaused reports whether the consumer is paused.
+func (c *consumer) IsPaused() bool {
+	return c.intake.isPaused()
+}
+
+// Stop shuts the consumer down and waits for Consume to return, or until ctx is done.
+func (c *consumer) Stop(ctx context.Context) error {
+	c.stop()
+
+	finished := make(chan struct{})
+	go func() {
+		c.active.Wait()
+		close(finished)
 	}()
 
-	// Declare queue as durable for production reliability
-	q, err := ch.QueueDeclare(
-		c.queueName, // name
-		true,        // durable - survive broker restart
-		false,       // auto-delete
-		false,       // exclusive
-		false,       // no-wait
-		nil,         // arguments
-	)
-	if err != nil {
-		return fmt.Errorf("error declaring queue: %w", err)
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for consumer to stop: %w", ctx.Err())
 	}
+}
 
-	// Set QoS to limit unacknowledged messages per consumer
-	if err := ch.Qos(concurrency, 0, false); err != nil {
-		return fmt.Errorf("error setting QoS: %w", err)
+// openChannel opens a channel and declares the queue.
+// The caller is responsible for closing the returned channel.
+func (c *consumer) openChannel() (*amqp091.Channel, chan *amqp091.Error, error) {
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening channel: %w", err)
 	}
 
-	msgs, err := ch.Consume(
-		q.Name, // queue name
-		c.name, // consumer name
-		false,  // auto-ack (acknowledgement is handled by the handler)
-		false,  // exclusive (only this consumer can access the queue)
-		false,  // no-local (can't consume messages from this connection)
-		false,  // no-wait (don't wait for the server to confirm the request)
-		nil,    // args (optional arguments)
-	)
+	closeChan := make(chan *amqp091.Error, 1)
+	ch.NotifyClose(closeChan)
 
+	// Declare queue as durable for production reliability, unless it must already exist
+	if c.passiveDeclare {
+		_, err = ch.QueueDeclarePassive(c.queueName, true, false, false, false, nil)
+	} else {
+		err = c.queues.declareQueue(ch, c.queueName)
+	}
 	if err != nil {
-		return fmt.Errorf("error consuming messages: %w", err)
+		c.closeChannel(ch)
+		return nil, nil, fmt.Errorf("error declaring queue: %w", err)
 	}
 
-	slog.Info("consumer started", slog.String("handler", c.name), slog.Int("concurrency", concurrency))
+	return ch, closeChan, nil
+}
 
-	wg := sync.WaitGroup{}
-	semaphore := make(chan struct{}, concurrency)
+// closeChannel closes ch and logs failures.
+func (c *consumer) closeChannel(ch *amqp091.Channel) {
+	if err := ch.Close(); err != nil {
+		c.logger.Error("error closing channel", slog.String("error", err.Error()))
+	}
+}
+
+// watchShutdown returns a channel that is closed when the AMQP channel closes, the
+// consumer context is canceled or Stop is called, and a function reporting the channel
+// close error, if any.
+func (c *consumer) watchShutdown(closeChan chan *amqp091.Error) (<-chan struct{}, func() error) {
 	done := make(chan struct{})
 	var shutdownErr error
 
-	// Cleanup goroutine
 	go func() {
 		select {
 		case err := <-closeChan:
 			if err != nil {
-				slog.Error("channel closed with error", slog.String("error", err.Error()), slog.String("handler", c.name))
+				c.logger.Error("channel closed with error", slog.String("error", err.Error()))
 				shutdownErr = fmt.Errorf("channel closed: %w", err)
 			} else {
-				slog.Info("channel closed gracefully", slog.String("handler", c.name))
+				c.logger.Info("channel closed gracefully")
 			}
 			close(done)
-		case <-c.context.Done():
-			slog.Info("context canceled", slog.String("handler", c.name))
+		case <-c.stopCtx.Done():
+			c.logger.Info("context canceled")
 			close(done)
 		}
 	}()
 
-	// Message processing loop
-	for {
-		select {
-		case <-done:
-			slog.Info("stopping consumer, waiting for in-flight messages", slog.String("handler", c.name))
-			wg.Wait()
-			slog.Info("consumer stopped", slog.String("handler", c.name))
-			return shutdownErr
-
-		case msg, ok := <-msgs:
-			if !ok {
-				// Channel closed
-				slog.Info("messages channel closed", slog.String("handler", c.name))
-				wg.Wait()
-				return nil
-			}
+	return done, func() error { return shutdownErr }
+}
 
-			if len(msg.Body) == 0 {
-				if err := msg.Ack(false); err != nil {
-					slog.Error("failed to ack empty message", slog.String("error", err.Error()), slog.String("handler", c.name))
-				}
-				continue
-			}
+// HandleMessage wraps handler invocation with ack/nack, tracing, instrumentation and panic recovery.
+// The caller acquires the semaphore slot and adds to wg before calling it; both are
+// released when the message has been settled.
+func (c *consumer) HandleMessage(msg amqp091.Delivery, wg *sync.WaitGroup, semaphore chan struct{}) {
+	defer wg.Done()
+	defer func() { <-semaphore }()
+
+	c.processDelivery(&msg, func(ctx context.Context, err error) {
+		if err != nil {
+			c.logger.Log(ctx, c.errorLevel(err), "error handling message",
+				slog.String("error", err.Error()))
+			c.nack(ctx, &msg, !errors.Is(err, ErrDeadLetter))
+			return
+		}
 
-			go c.HandleMessage(msg, &wg, semaphore)
+		c.logger.Debug("message handled successfully")
+		if c.ack(ctx, &msg) {
+			c.claims.release(ctx, c.logger, msg.Headers)
 		}
+	})
+}
+
+// processDelivery invokes the handler for msg inside a consumer span, records the
+// instrumentation events and passes the handler result to settle before the span ends.
+// The handler result reaches the circuit breaker before settle, so a half-open breaker
+// reopens or closes before the next probe is delivered; decode failures are not fed
+// to the breaker, as they are caused by the message rather than the handler.
+func (c *consumer) processDelivery(msg *amqp091.Delivery, settle func(ctx context.Context, err error)) {
+	ctx, span := startProcessSpan(c.context, c.tracer, c.propagator, c.queueName, c.name, msg)
+	defer span.End()
+
+	c.instrumentation.MessageReceived(ctx, c.queueName, len(msg.Body))
+	c.instrumentation.InFlight(ctx, c.queueName, 1)
+	defer c.instrumentation.InFlight(ctx, c.queueName, -1)
+
+	start := time.Now()
+	err := c.decodeDelivery(ctx, msg)
+	if err == nil {
+		// Record the payload size rather than the encoded one
+		span.SetAttributes(semconv.MessagingMessageBodySize(len(msg.Body)))
+		err = c.invokeHandler(ctx, msg)
+		c.recordResult(err)
 	}
+	c.instrumentation.MessageHandled(ctx, c.queueName, time.Since(start), err)
+	recordSpanError(span, err)
+
+	settle(ctx, err)
 }
 
-// HandleMessage wraps handler invocation with ack/nack and panic recovery.
-func (c *consumer) HandleMessage(msg amqp091.Delivery, wg *sync.WaitGroup, semaphore chan struct{}) {
-	semaphore <- struct{}{}
-	wg.Add(1)
+// ack acknowledges the delivery, records the outcome and reports whether it succeeded.
+func (c *consumer) ack(ctx context.Context, msg *amqp091.Delivery) bool {
+	if err := msg.Ack(false); err != nil {
+		c.logger.Error("failed to ack message", slog.String("error", err.Error()))
+		return false
+	}
+	c.instrumentation.MessageAcked(ctx, c.queueName)
+	return true
+}
 
-	defer wg.Done()
-	defer func() { <-semaphore }()
+// nack rejects the delivery and records the outcome.
+func (c *consumer) nack(ctx context.Context, msg *amqp091.Delivery, requeue bool) {
+	if err := msg.Nack(false, requeue); err != nil {
+		c.logger.Error("failed to nack message", slog.String("error", err.Error()))
+		return
+	}
+	c.instrumentation.MessageNacked(ctx, c.queueName, requeue)
+}
+
+// decodeDelivery fetches claim-checked payloads, decrypts and decompresses the
+// delivery body in place, undoing what the producer applied before publishing, and
+// validates the result against its schema. Errors wrap ErrDecode.
+func (c *consumer) decodeDelivery(ctx context.Context, msg *amqp091.Delivery) error {
+	if err := c.decode(ctx, msg); err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	return nil
+}
+
+func (c *consumer) decode(ctx context.Context, msg *amqp091.Delivery) error {
+	if err := c.claims.fetch(ctx, msg); err != nil {
+		return err
+	}
+	if err := decryptDelivery(ctx, c.keys, msg); err != nil {
+		return err
+	}
+	if err := decompressDelivery(msg); err != nil {
+		return err
+	}
+	return c.schemas.validateDelivery(ctx, msg)
+}
+
+// invokeHandler calls the handler, preferring ContextHandler when it is implemented,
+// and converts panics into errors.
+func (c *consumer) invokeHandler(ctx context.Context, msg *amqp091.Delivery) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			slog.Error("panic recovered in message handler",
-				slog.Any("panic", r),
-				slog.String("handler", c.name))
-			if err := msg.Nack(false, true); err != nil {
-				slog.Error("failed to nack message after panic", slog.String("error", err.Error()), slog.String("handler", c.name))
-			}
+			c.logger.Error("panic recovered in message handler", slog.Any("panic", r))
+			err = fmt.Errorf("panic: %v", r)
 		}
 	}()
 
-	if err := c.handler.HandleMessage(msg.Body); err != nil {
-		slog.Error("error handling message",
-			slog.String("error", err.Error()),
-			slog.String("handler", c.name))
-		if err := msg.Nack(false, true); err != nil {
-			slog.Error("failed to nack message", slog.String("error", err.Error()), slog.String("handler", c.name))
-		}
-		return
-	}
-
-	slog.Debug("message handled successfully", slog.String("handler", c.name))
-	if err := msg.Ack(false); err != nil {
-		slog.Error("failed to ack message", slog.String("error", err.Error()), slog.String("handler", c.name))
+	if h, ok := c.handler.(ContextHandler); ok {
+		return h.HandleMessageContext(ctx, newMessage(msg))
 	}
+	return c.handler.HandleMessage(msg.Body)
 }
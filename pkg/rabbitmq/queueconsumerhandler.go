@@ -1,6 +1,67 @@
 package rabbitmq
 
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ErrDeadLetter is returned by handlers, possibly wrapped, to reject a message without
+// requeueing it: RabbitMQ routes it to the dead-letter exchange of the queue, or drops
+// it when the queue has none. Any other error requeues the message.
+// Stream consumers skip the message instead of retrying it.
+var ErrDeadLetter = errors.New("dead-letter message")
+
+// ErrDecode wraps the errors of messages that could not be turned back into their
+// payload before invoking the handler: claim-check fetch, decryption, decompression
+// or schema validation failures. Instrumentation receives it in MessageHandled.
+var ErrDecode = errors.New("failed to decode message")
+
 // ConsumerHandler processes a single message payload.
 type ConsumerHandler interface {
 	HandleMessage([]byte) error
 }
+
+// ContextHandler is an optional interface a ConsumerHandler may implement.
+// When implemented, the consumer calls HandleMessageContext instead of HandleMessage,
+// passing a context that carries the span extracted from the message headers
+// together with the message properties.
+type ContextHandler interface {
+	HandleMessageContext(ctx context.Context, msg *Message) error
+}
+
+// Message is a delivered message with its AMQP properties.
+type Message struct {
+	Body            []byte
+	Headers         map[string]any
+	ContentType     string
+	ContentEncoding string
+	Type            string
+	MessageID       string
+	CorrelationID   string
+	Priority        uint8
+	Timestamp       time.Time
+	Redelivered     bool
+	Exchange        string
+	RoutingKey      string
+}
+
+// newMessage converts an AMQP delivery into a Message.
+func newMessage(d *amqp091.Delivery) *Message {
+	return &Message{
+		Body:            d.Body,
+		Headers:         d.Headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		Type:            d.Type,
+		MessageID:       d.MessageId,
+		CorrelationID:   d.CorrelationId,
+		Priority:        d.Priority,
+		Timestamp:       d.Timestamp,
+		Redelivered:     d.Redelivered,
+		Exchange:        d.Exchange,
+		RoutingKey:      d.RoutingKey,
+	}
+}
@@ -3,10 +3,15 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"sync"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Producer publishes messages to RabbitMQ queues.
@@ -18,40 +23,83 @@ type Producer interface {
 	// The body will be automatically marshaled to JSON.
 	// Messages are published as persistent (survive broker restarts).
 	// If the channel is closed, Publish will attempt to reconnect automatically.
-	Publish(queueName string, body any) error
-	// Close closes the producer's channel.
+	Publish(queueName string, body any, opts ...PublishOption) error
+	// PublishWithContext behaves like Publish but uses ctx for the publish call and
+	// propagates the trace context found in ctx through the message headers.
+	PublishWithContext(ctx context.Context, queueName string, body any, opts ...PublishOption) error
+	// PublishToExchange sends a message to exchange with routingKey. For an exchange
+	// declared with DeclareShardedQueues, routingKey is the hash key selecting the shard.
+	// The exchange must exist, and delayed messages are not supported.
+	PublishToExchange(ctx context.Context, exchange, routingKey string, body any, opts ...PublishOption) error
+	// Close closes the producer's channel right away. Messages not yet confirmed by
+	// the broker may be lost; use Shutdown to wait for their confirmations. Close keeps
+	// its signature, rather than taking a context and draining, so existing callers and
+	// io.Closer users keep compiling and behaving as before.
 	Close() error
+	// Shutdown stops accepting new publishes, waits until the broker confirms every
+	// message already published or ctx is done, and closes the channel. When ctx ends
+	// first, it returns an *UnconfirmedError listing the unconfirmed message IDs; it
+	// also returns one listing the messages the broker rejected, if any.
+	Shutdown(ctx context.Context) error
 }
 
 type producer struct {
-	conn    *amqp091.Connection
-	ch      *amqp091.Channel
-	mu      sync.Mutex
-	context context.Context
+	conn            *amqp091.Connection
+	ch              *amqp091.Channel
+	confirms        *confirmTracker
+	nacks           nackLog
+	lock            chan struct{}
+	closed          atomic.Bool
+	context         context.Context
+	tracer          trace.Tracer
+	propagator      propagation.TextMapPropagator
+	instrumentation Instrumentation
+	queues          *queueRegistry
+	flow            *flowControl
+	compression     *compression
+	keys            KeyProvider
+	claims          *claimCheck
+	delays          *delayer
+	schemas         *schemaValidator
+	logger          *slog.Logger
+	onChannelClose  func(error)
 }
 
 // NewProducer creates a new producer for publishing messages.
-// The producer maintains a persistent channel that is automatically monitored.
-// If the channel closes, it will be automatically recreated on the next Publish call.
-// Remember to call Close() when done to release resources.
+// The producer maintains a persistent channel in publisher confirm mode that is
+// automatically monitored. If the channel closes, it will be automatically recreated
+// on the next Publish call.
+// Remember to call Shutdown() or Close() when done to release resources.
 func (c *client) NewProducer() (Producer, error) {
-	ch, err := c.conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open channel: %w", err)
-	}
-
-	p := &producer{
-		conn:    c.conn,
-		ch:      ch,
-		context: c.context,
+	p := c.newProducer()
+	if err := p.openChannel(); err != nil {
+		return nil, err
 	}
 
-	// Monitor channel closures
-	go p.monitorChannel()
-
 	return p, nil
 }
 
+// newProducer builds a producer with the client settings, without opening its channel.
+func (c *client) newProducer() *producer {
+	return &producer{
+		conn:            c.conn,
+		lock:            make(chan struct{}, 1),
+		context:         c.context,
+		tracer:          c.tracer,
+		propagator:      c.propagator,
+		instrumentation: c.instrumentation,
+		queues:          c.queues,
+		flow:            c.flow,
+		compression:     c.compression,
+		keys:            c.keys,
+		claims:          c.claims,
+		delays:          c.delays,
+		schemas:         c.schemas,
+		logger:          c.logger,
+		onChannelClose:  c.onChannelClose,
+	}
+}
+
 // Publish sends a message to the specified queue.
 // The message body is automatically marshaled to JSON and published as persistent.
 //
@@ -61,22 +109,121 @@ func (c *client) NewProducer() (Producer, error) {
 // the method will return an error and you must create a new Client.
 //
 // Thread-safe: Multiple goroutines can safely call Publish concurrently.
-func (p *producer) Publish(queueName string, body any) error {
+func (p *producer) Publish(queueName string, body any, opts ...PublishOption) error {
+	return p.PublishWithContext(p.context, queueName, body, opts...)
+}
+
+// PublishWithContext sends a message to the specified queue using ctx.
+// A producer span is started as a child of the span in ctx and its context is
+// injected into the message headers so consumers can continue the trace.
+//
+// While the broker blocks the connection, PublishWithContext follows the client
+// BlockedPolicy: it fails with ErrConnectionBlocked or waits until the connection is
+// unblocked or ctx is done.
+//
+// Thread-safe: Multiple goroutines can safely call PublishWithContext concurrently.
+func (p *producer) PublishWithContext(ctx context.Context, queueName string, body any, opts ...PublishOption) error {
 	if queueName == "" {
 		return fmt.Errorf("queue name cannot be empty")
 	}
+	return p.send(ctx, "", queueName, body, opts)
+}
+
+// PublishToExchange sends a message to exchange with routingKey, like PublishWithContext.
+func (p *producer) PublishToExchange(ctx context.Context, exchange, routingKey string, body any, opts ...PublishOption) error {
+	if exchange == "" {
+		return fmt.Errorf("exchange name cannot be empty")
+	}
+	return p.send(ctx, exchange, routingKey, body, opts)
+}
+
+// send builds the message and publishes it to exchange, or to the queue named by
+// routingKey through the default exchange when exchange is empty.
+func (p *producer) send(ctx context.Context, exchange, routingKey string, body any, opts []PublishOption) error {
+	if ctx == nil {
+		return fmt.Errorf("context cannot be nil")
+	}
 
 	if body == nil {
 		return fmt.Errorf("message body cannot be nil")
 	}
 
+	if p.closed.Load() {
+		return ErrProducerClosed
+	}
+
 	bytes, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message body: %w", err)
 	}
 
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	msg := amqp091.Publishing{
+		ContentType:  "application/json",
+		Body:         bytes,
+		DeliveryMode: amqp091.Persistent, // 2 = persistent
+		MessageId:    newMessageID(),
+	}
+	for _, opt := range opts {
+		opt(&msg)
+	}
+	if _, delayed := messageDelay(&msg); exchange != "" && delayed {
+		return fmt.Errorf("delayed messages can only be published to queues")
+	}
+	if err := p.schemas.validatePublishing(ctx, &msg); err != nil {
+		return err
+	}
+
+	// Metrics and claim keys are named after the destination
+	destination := routingKey
+	if exchange != "" {
+		destination = exchange
+	}
+
+	// The span starts before encoding, so it records the payload size and covers the
+	// compression, encryption and claim-check steps
+	ctx, span := startPublishSpan(ctx, p.tracer, p.propagator, exchange, routingKey, &msg)
+	defer span.End()
+
+	start := time.Now()
+	err = p.encode(ctx, destination, &msg)
+	if err == nil {
+		err = p.publish(ctx, exchange, routingKey, msg)
+		if err != nil {
+			// The broker never got the reference, so the offloaded payload is orphaned
+			p.claims.discard(ctx, p.logger, msg.Headers)
+		}
+	}
+	p.instrumentation.MessagePublished(ctx, destination, len(msg.Body), time.Since(start), err)
+	recordSpanError(span, err)
+	return err
+}
+
+// encode compresses and encrypts the body of msg, then offloads it to the claim store
+// when it is still too large.
+func (p *producer) encode(ctx context.Context, destination string, msg *amqp091.Publishing) error {
+	if err := p.compression.apply(msg); err != nil {
+		return err
+	}
+	if err := encryptPublishing(ctx, p.keys, msg); err != nil {
+		return err
+	}
+	return p.claims.offload(ctx, destination, msg)
+}
+
+// publish sends msg to exchange. Messages for the default exchange declare their queue
+// first and follow the delay strategy when delayed.
+func (p *producer) publish(ctx context.Context, exchange, routingKey string, msg amqp091.Publishing) error {
+	if err := p.flow.admit(ctx); err != nil {
+		return err
+	}
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer p.release()
+
+	if p.closed.Load() {
+		return ErrProducerClosed
+	}
 
 	// Check if channel is closed and try to reconnect
 	if p.ch == nil || p.ch.IsClosed() {
@@ -86,85 +233,129 @@ func (p *producer) Publish(queueName string, body any) error {
 		// Channel reconnected successfully, continue with publish
 	}
 
-	// Declare queue to ensure it exists
-	_, err = p.ch.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // auto-delete
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
-		return fmt.Errorf("failed to declare queue: %w", err)
-	}
-
-	err = p.ch.PublishWithContext(
-		p.context,
-		"",        // exchange (empty for default)
-		queueName, // routing key (queue name)
-		false,     // mandatory
-		false,     // immediate
-		amqp091.Publishing{
-			ContentType:  "application/json",
-			Body:         bytes,
-			DeliveryMode: amqp091.Persistent, // 2 = persistent
-		},
+	if exchange == "" {
+		queueName := routingKey
+
+		// Declare queue to ensure it exists
+		if err := p.queues.declareQueue(p.ch, queueName); err != nil {
+			return fmt.Errorf("failed to declare queue: %w", err)
+		}
+
+		// Delayed messages go through the configured delay strategy instead
+		if delay, ok := messageDelay(&msg); ok {
+			var err error
+			if exchange, routingKey, err = p.delays.route(p.ch, queueName, delay); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Track the message until the broker confirms it, so Shutdown can wait for it
+	tag := p.ch.GetNextPublishSeqNo()
+	p.confirms.add(tag, msg.MessageId)
+
+	err := p.ch.PublishWithContext(
+		ctx,
+		exchange,   // exchange (empty for default)
+		routingKey, // routing key (queue name)
+		false,      // mandatory
+		false,      // immediate
+		msg,
 	)
 
 	if err != nil {
+		p.confirms.remove(tag)
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
 	return nil
 }
 
-// Close closes the producer's channel gracefully.
-// This should be called when done publishing messages.
+// Close closes the producer's channel right away, without waiting for confirmations.
 func (p *producer) Close() error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.closed.Store(true)
+
+	if err := p.acquire(context.Background()); err != nil {
+		return err
+	}
+	defer p.release()
+
+	return p.closeChannel()
+}
+
+// Shutdown stops accepting new publishes, waits for the confirmations of the messages
+// already published and closes the channel.
+func (p *producer) Shutdown(ctx context.Context) error {
+	p.closed.Store(true)
+
+	// Wait for an in-flight publish, so its message is tracked before draining
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer p.release()
 
-	if p.ch == nil {
+	var waitErr error
+	if p.confirms != nil {
+		waitErr = p.confirms.wait(ctx)
+	}
+	return errors.Join(p.nacks.report(waitErr), p.closeChannel())
+}
+
+// closeChannel closes the channel if still open. Must be called with the producer lock held.
+func (p *producer) closeChannel() error {
+	if p.ch == nil || p.ch.IsClosed() {
 		return nil
 	}
 	return p.ch.Close()
 }
 
+// acquire takes the producer lock or gives up when ctx is done. A publish stuck on a
+// blocked connection keeps holding the lock, so waiting for it must honor ctx.
+func (p *producer) acquire(ctx context.Context) error {
+	select {
+	case p.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		if p.flow.isBlocked() {
+			return fmt.Errorf("%w: %w", ErrConnectionBlocked, ctx.Err())
+		}
+		return fmt.Errorf("waiting for producer channel: %w", ctx.Err())
+	}
+}
+
+// release returns the producer lock.
+func (p *producer) release() {
+	<-p.lock
+}
+
 // reconnect attempts to recreate the channel when it's closed.
-// Must be called with p.mu locked.
+// Must be called with the producer lock held.
 func (p *producer) reconnect() error {
 	if p.conn == nil || p.conn.IsClosed() {
 		return fmt.Errorf("connection is closed, cannot reconnect channel")
 	}
+	return p.openChannel()
+}
 
+// openChannel opens a channel in publisher confirm mode and starts watching its
+// confirmations and closure. Must be called with the producer lock held.
+func (p *producer) openChannel() error {
 	ch, err := p.conn.Channel()
 	if err != nil {
-		return fmt.Errorf("failed to create new channel: %w", err)
+		return fmt.Errorf("failed to open channel: %w", err)
 	}
-
-	p.ch = ch
-	go p.monitorChannel()
-	return nil
-}
-
-// monitorChannel watches for channel closures and logs them.
-func (p *producer) monitorChannel() {
-	if p.ch == nil {
-		return
+	if err := ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
 	}
 
-	closeChan := make(chan *amqp091.Error, 1)
-	p.ch.NotifyClose(closeChan)
+	// Register the listeners before any publish, so no confirmation is missed
+	tracker := newConfirmTracker()
+	confirms := ch.NotifyPublish(make(chan amqp091.Confirmation, confirmBufferSize))
+	closeChan := ch.NotifyClose(make(chan *amqp091.Error, 1))
+	go p.watchChannel(tracker, confirms, closeChan)
 
-	select {
-	case err := <-closeChan:
-		if err != nil {
-			// Channel closed with error - will try to reconnect on next Publish
-			_ = err // Log if needed
-		}
-	case <-p.context.Done():
-		// Context canceled
-		return
-	}
+	p.ch = ch
+	p.confirms = tracker
+	return nil
 }
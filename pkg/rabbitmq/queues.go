@@ -0,0 +1,87 @@
+package rabbitmq
+
+import (
+	"errors"
+	"maps"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// queueRegistry remembers the arguments queues were declared with through the client,
+// so producers and consumers created from it redeclare them equivalently. RabbitMQ
+// closes the channel when a queue is redeclared with different arguments.
+type queueRegistry struct {
+	mu   sync.RWMutex
+	args map[string]amqp091.Table
+}
+
+// set records the declaration arguments of a queue.
+func (r *queueRegistry) set(name string, args amqp091.Table) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.args == nil {
+		r.args = make(map[string]amqp091.Table)
+	}
+	r.args[name] = maps.Clone(args)
+}
+
+// remove forgets a deleted queue.
+func (r *queueRegistry) remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.args, name)
+}
+
+// get returns the declaration arguments of a queue and whether it is known.
+func (r *queueRegistry) get(name string) (amqp091.Table, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	args, ok := r.args[name]
+	return maps.Clone(args), ok
+}
+
+// declareQueue declares a durable queue with the arguments registered for it, if any.
+func (r *queueRegistry) declareQueue(ch *amqp091.Channel, name string) error {
+	args, _ := r.get(name)
+	_, err := ch.QueueDeclare(
+		name,  // name
+		true,  // durable - survive broker restart
+		false, // auto-delete
+		false, // exclusive
+		false, // no-wait
+		args,  // arguments
+	)
+	return err
+}
+
+// DeclarePriorityQueue declares a durable queue that delivers messages with a higher
+// priority first (x-max-priority). maxPriority is the highest priority the queue
+// supports; RabbitMQ recommends values up to 10, as each level has a runtime cost.
+// Producers and consumers created from this client declare the queue with the same
+// arguments, so call it before publishing to or consuming from the queue.
+func (k *client) DeclarePriorityQueue(name string, maxPriority uint8) error {
+	if name == "" {
+		return errors.New("queue name cannot be empty")
+	}
+	if maxPriority == 0 {
+		return errors.New("max priority must be greater than 0")
+	}
+
+	return k.declareQueue(name, amqp091.Table{"x-max-priority": int32(maxPriority)})
+}
+
+// declareQueue declares a durable queue with args on a short-lived channel and
+// registers the arguments for producers and consumers.
+func (k *client) declareQueue(name string, args amqp091.Table) error {
+	err := k.withChannel(func(ch *amqp091.Channel) error {
+		_, err := ch.QueueDeclare(name, true, false, false, false, args)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	k.queues.set(name, args)
+
+	return nil
+}
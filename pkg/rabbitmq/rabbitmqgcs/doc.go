@@ -0,0 +1,17 @@
+// Package rabbitmqgcs provides a rabbitmq.ClaimStore backed by Google Cloud Storage,
+// so payloads too large for RabbitMQ are offloaded to a bucket through the client
+// returned by gcs.NewClient from pkg/gcp/gcs.
+//
+// Example:
+//
+//	storage, err := gcs.NewClient(ctx, &gcs.Cfg{BucketName: "rabbitmq-claims"})
+//	if err != nil {
+//		panic(err)
+//	}
+//
+//	client, err := rabbitmq.NewClient(ctx, url, rabbitmq.WithClaimCheck(rabbitmq.ClaimCheckCfg{
+//		Store:          rabbitmqgcs.NewStore(storage),
+//		Threshold:      2 << 20, // offload payloads of 2 MiB or more
+//		DeleteAfterAck: true,
+//	}))
+package rabbitmqgcs
@@ -0,0 +1,74 @@
+package rabbitmqgcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zarvhq/zarv-go/pkg/gcp/gcs"
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+const contentType = "application/octet-stream"
+
+// ObjectClient is the subset of the client returned by gcs.NewClient used by Store.
+type ObjectClient interface {
+	GetObject(key string) (*gcs.Object, error)
+	PutObject(obj *gcs.Object) error
+	DeleteObject(key string) error
+}
+
+// Store is a rabbitmq.ClaimStore that keeps payloads as objects in the bucket of an
+// ObjectClient. The gcs client uses the context it was created with, so the contexts
+// passed to Store methods do not cancel storage calls.
+type Store struct {
+	client ObjectClient
+}
+
+var _ rabbitmq.ClaimStore = (*Store)(nil)
+
+// NewStore returns a claim store writing to the bucket of client, typically the
+// client returned by gcs.NewClient.
+func NewStore(client ObjectClient) *Store {
+	return &Store{client: client}
+}
+
+// Put implements rabbitmq.ClaimStore.
+func (s *Store) Put(_ context.Context, key string, data []byte) error {
+	return s.client.PutObject(&gcs.Object{
+		Key:         key,
+		Data:        data,
+		ContentType: contentType,
+	})
+}
+
+// Get implements rabbitmq.ClaimStore.
+func (s *Store) Get(_ context.Context, key string) ([]byte, error) {
+	obj, err := s.client.GetObject(key)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return obj.Data, nil
+}
+
+// Delete implements rabbitmq.ClaimStore. Deleting a missing object succeeds.
+func (s *Store) Delete(_ context.Context, key string) error {
+	if err := s.client.DeleteObject(key); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// notFound wraps gcs not-found errors with rabbitmq.ErrClaimNotFound.
+func notFound(err error) error {
+	if isNotFound(err) {
+		return fmt.Errorf("%w: %w", rabbitmq.ErrClaimNotFound, err)
+	}
+	return err
+}
+
+// isNotFound reports whether err is a gcs.ErrObjectNotFound error, which gcs reports
+// as a message prefix rather than a sentinel value.
+func isNotFound(err error) bool {
+	return strings.HasPrefix(err.Error(), gcs.ErrObjectNotFound)
+}
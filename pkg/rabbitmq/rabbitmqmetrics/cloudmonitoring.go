@@ -0,0 +1,253 @@
+package rabbitmqmetrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zarvhq/zarv-go/pkg/gcp/metrics"
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+const (
+	defaultPrefix   = "custom.googleapis.com/rabbitmq/"
+	defaultInterval = time.Minute
+
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+)
+
+// CloudMonitoringCfg configures the Cloud Monitoring instrumentation.
+type CloudMonitoringCfg struct {
+	// Prefix is prepended to every metric name. Defaults to "custom.googleapis.com/rabbitmq/".
+	Prefix string
+	// Interval between writes to Cloud Monitoring. Defaults to one minute.
+	// Cloud Monitoring rejects points written more often than every 5 seconds per series.
+	Interval time.Duration
+	// Buckets are the upper bounds, in seconds, of the handler latency histogram.
+	// Defaults to the same boundaries used by the OpenTelemetry implementation.
+	Buckets []float64
+	// Labels are added to every time series (e.g. service name).
+	Labels map[string]string
+	// Logger receives flush errors. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+type seriesKey struct {
+	name       string
+	queue      string
+	labelKey   string
+	labelValue string
+}
+
+// CloudMonitoring aggregates producer and consumer events in memory and periodically
+// writes them to Cloud Monitoring through a metrics.Client.
+//
+// Counters are written as CUMULATIVE metrics starting at creation time and the in-flight
+// count as a GAUGE. Handler latency is exported as a cumulative histogram: one
+// handler_latency_bucket series per "le" label, plus handler_latency_sum and handled.
+type CloudMonitoring struct {
+	client   metrics.Client
+	prefix   string
+	buckets  []float64
+	labels   map[string]string
+	logger   *slog.Logger
+	start    time.Time
+	mu       sync.Mutex
+	counters map[seriesKey]float64
+	gauges   map[seriesKey]float64
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+var _ rabbitmq.Instrumentation = (*CloudMonitoring)(nil)
+
+// NewCloudMonitoring creates the instrumentation and starts the background flush loop,
+// which runs until ctx is canceled or Close is called.
+func NewCloudMonitoring(ctx context.Context, client metrics.Client, cfg *CloudMonitoringCfg) (*CloudMonitoring, error) {
+	if ctx == nil {
+		return nil, errors.New("context cannot be nil")
+	}
+	if client == nil {
+		return nil, errors.New("metrics client cannot be nil")
+	}
+	if cfg == nil {
+		cfg = &CloudMonitoringCfg{}
+	}
+
+	m := &CloudMonitoring{
+		client:   client,
+		prefix:   cfg.Prefix,
+		buckets:  cfg.Buckets,
+		labels:   cfg.Labels,
+		logger:   cfg.Logger,
+		start:    time.Now().UTC(),
+		counters: make(map[seriesKey]float64),
+		gauges:   make(map[seriesKey]float64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if m.prefix == "" {
+		m.prefix = defaultPrefix
+	}
+	if m.logger == nil {
+		m.logger = slog.Default()
+	}
+	if len(m.buckets) == 0 {
+		m.buckets = durationBuckets
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	go m.run(ctx, interval)
+
+	return m, nil
+}
+
+// MessagePublished counts a publish attempt.
+func (m *CloudMonitoring) MessagePublished(_ context.Context, queueName string, _ int, _ time.Duration, err error) {
+	m.add(seriesKey{name: "published", queue: queueName, labelKey: "outcome", labelValue: outcome(err)}, 1)
+}
+
+// MessageReceived counts a delivery.
+func (m *CloudMonitoring) MessageReceived(_ context.Context, queueName string, _ int) {
+	m.add(seriesKey{name: "consumed", queue: queueName}, 1)
+}
+
+// MessageHandled counts the handler outcome and records its latency in the histogram.
+func (m *CloudMonitoring) MessageHandled(_ context.Context, queueName string, duration time.Duration, err error) {
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[seriesKey{name: "handled", queue: queueName, labelKey: "outcome", labelValue: outcome(err)}]++
+	m.counters[seriesKey{name: "handler_latency_sum", queue: queueName}] += seconds
+	for _, le := range m.buckets {
+		if seconds <= le {
+			m.counters[seriesKey{name: "handler_latency_bucket", queue: queueName, labelKey: "le", labelValue: strconv.FormatFloat(le, 'g', -1, 64)}]++
+		}
+	}
+	m.counters[seriesKey{name: "handler_latency_bucket", queue: queueName, labelKey: "le", labelValue: "+Inf"}]++
+}
+
+// MessageAcked counts an acknowledgement.
+func (m *CloudMonitoring) MessageAcked(_ context.Context, queueName string) {
+	m.add(seriesKey{name: "acked", queue: queueName}, 1)
+}
+
+// MessageNacked counts a rejection.
+func (m *CloudMonitoring) MessageNacked(_ context.Context, queueName string, requeue bool) {
+	m.add(seriesKey{name: "nacked", queue: queueName, labelKey: "requeue", labelValue: strconv.FormatBool(requeue)}, 1)
+}
+
+// InFlight updates the in-flight gauge.
+func (m *CloudMonitoring) InFlight(_ context.Context, queueName string, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[seriesKey{name: "inflight", queue: queueName}] += float64(delta)
+}
+
+// Flush writes the current value of every series to Cloud Monitoring. When the client
+// implements metrics.BatchWriter, series are sent metrics.MaxBatchSize per request;
+// otherwise one request is made per series.
+func (m *CloudMonitoring) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	points := make([]metrics.Point, 0, len(m.counters)+len(m.gauges))
+	for key, value := range m.counters {
+		points = append(points, metrics.Point{MetricType: m.prefix + key.name, Labels: m.seriesLabels(key), Start: m.start, Value: value})
+	}
+	for key, value := range m.gauges {
+		points = append(points, metrics.Point{MetricType: m.prefix + key.name, Labels: m.seriesLabels(key), Value: value})
+	}
+	m.mu.Unlock()
+
+	batcher, ok := m.client.(metrics.BatchWriter)
+	if !ok {
+		return m.writeEach(ctx, points)
+	}
+
+	var errs []error
+	for batch := range slices.Chunk(points, metrics.MaxBatchSize) {
+		if err := batcher.WriteBatch(ctx, batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeEach writes points one request at a time, for clients without batch support.
+func (m *CloudMonitoring) writeEach(ctx context.Context, points []metrics.Point) error {
+	var errs []error
+	for _, point := range points {
+		var err error
+		if point.Start.IsZero() {
+			err = m.client.WriteGauge(ctx, point.MetricType, point.Labels, point.Value)
+		} else {
+			err = m.client.WriteCumulative(ctx, point.MetricType, point.Labels, point.Start, point.Value)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the flush loop and writes the final values.
+// It does not close the underlying metrics.Client.
+func (m *CloudMonitoring) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	<-m.done
+	return m.Flush(context.Background())
+}
+
+func (m *CloudMonitoring) run(ctx context.Context, interval time.Duration) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				m.logger.Error("failed to flush rabbitmq metrics", slog.String("error", err.Error()))
+			}
+		case <-m.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *CloudMonitoring) add(key seriesKey, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[key] += delta
+}
+
+func (m *CloudMonitoring) seriesLabels(key seriesKey) map[string]string {
+	labels := make(map[string]string, len(m.labels)+2)
+	maps.Copy(labels, m.labels)
+	labels["queue"] = key.queue
+	if key.labelKey != "" {
+		labels[key.labelKey] = key.labelValue
+	}
+	return labels
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return outcomeError
+	}
+	return outcomeSuccess
+}
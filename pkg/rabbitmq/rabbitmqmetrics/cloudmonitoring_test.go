@@ -0,0 +1,178 @@
+package rabbitmqmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zarvhq/zarv-go/pkg/gcp/metrics"
+)
+
+// fakeMetrics records what a CloudMonitoring writes, one entry per request.
+type fakeMetrics struct {
+	mu       sync.Mutex
+	requests [][]metrics.Point
+	err      error
+}
+
+func (f *fakeMetrics) WriteGauge(_ context.Context, metricType string, labels map[string]string, value float64) error {
+	return f.WriteBatch(context.Background(), []metrics.Point{{MetricType: metricType, Labels: labels, Value: value}})
+}
+
+func (f *fakeMetrics) WriteCumulative(_ context.Context, metricType string, labels map[string]string, start time.Time, value float64) error {
+	return f.WriteBatch(context.Background(), []metrics.Point{{MetricType: metricType, Labels: labels, Start: start, Value: value}})
+}
+
+func (f *fakeMetrics) Close() error { return nil }
+
+func (f *fakeMetrics) WriteBatch(_ context.Context, points []metrics.Point) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, points)
+	return f.err
+}
+
+// series returns every written point by metric name and label values.
+func (f *fakeMetrics) series() map[string]metrics.Point {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	series := make(map[string]metrics.Point)
+	for _, request := range f.requests {
+		for _, point := range request {
+			key := point.MetricType
+			for _, label := range []string{"queue", "outcome", "le", "requeue", "service"} {
+				if value, ok := point.Labels[label]; ok {
+					key += fmt.Sprintf(",%s=%s", label, value)
+				}
+			}
+			series[key] = point
+		}
+	}
+	return series
+}
+
+// unbatchedMetrics hides the WriteBatch method of fakeMetrics.
+type unbatchedMetrics struct {
+	metrics.Client
+}
+
+func newTestCloudMonitoring(t *testing.T, client metrics.Client, cfg *CloudMonitoringCfg) *CloudMonitoring {
+	t.Helper()
+	if cfg == nil {
+		cfg = &CloudMonitoringCfg{}
+	}
+	cfg.Interval = time.Hour
+	m, err := NewCloudMonitoring(t.Context(), client, cfg)
+	if err != nil {
+		t.Fatalf("NewCloudMonitoring: %v", err)
+	}
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
+func TestCloudMonitoringSeries(t *testing.T) {
+	client := &fakeMetrics{}
+	m := newTestCloudMonitoring(t, client, &CloudMonitoringCfg{
+		Prefix:  "custom.googleapis.com/test/",
+		Buckets: []float64{0.1, 1},
+		Labels:  map[string]string{"service": "billing"},
+	})
+	ctx := context.Background()
+
+	m.MessagePublished(ctx, "orders", 10, time.Millisecond, nil)
+	m.MessagePublished(ctx, "orders", 10, time.Millisecond, errors.New("boom"))
+	m.MessageReceived(ctx, "orders", 10)
+	m.MessageHandled(ctx, "orders", 500*time.Millisecond, nil)
+	m.MessageAcked(ctx, "orders")
+	m.MessageNacked(ctx, "orders", true)
+	m.InFlight(ctx, "orders", 2)
+	m.InFlight(ctx, "orders", -1)
+
+	if err := m.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	const prefix = "custom.googleapis.com/test/"
+	want := map[string]float64{
+		prefix + "published,queue=orders,outcome=success,service=billing":      1,
+		prefix + "published,queue=orders,outcome=error,service=billing":        1,
+		prefix + "consumed,queue=orders,service=billing":                       1,
+		prefix + "handled,queue=orders,outcome=success,service=billing":        1,
+		prefix + "handler_latency_sum,queue=orders,service=billing":            0.5,
+		prefix + "handler_latency_bucket,queue=orders,le=1,service=billing":    1,
+		prefix + "handler_latency_bucket,queue=orders,le=+Inf,service=billing": 1,
+		prefix + "acked,queue=orders,service=billing":                          1,
+		prefix + "nacked,queue=orders,requeue=true,service=billing":            1,
+		prefix + "inflight,queue=orders,service=billing":                       1,
+	}
+	series := client.series()
+	if len(series) != len(want) {
+		t.Errorf("wrote %d series, want %d: %v", len(series), len(want), series)
+	}
+	for key, value := range want {
+		point, ok := series[key]
+		if !ok {
+			t.Errorf("series %s not written", key)
+			continue
+		}
+		if point.Value != value {
+			t.Errorf("series %s = %v, want %v", key, point.Value, value)
+		}
+		if gauge := key == prefix+"inflight,queue=orders,service=billing"; gauge != point.Start.IsZero() {
+			t.Errorf("series %s has start %v, want a start only on cumulative series", key, point.Start)
+		}
+	}
+}
+
+func TestCloudMonitoringBatchesSeries(t *testing.T) {
+	client := &fakeMetrics{}
+	m := newTestCloudMonitoring(t, client, nil)
+	for i := range 450 {
+		m.MessageAcked(context.Background(), fmt.Sprintf("queue-%d", i))
+	}
+
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	var sizes []int
+	for _, request := range client.requests {
+		sizes = append(sizes, len(request))
+	}
+	if fmt.Sprint(sizes) != fmt.Sprint([]int{200, 200, 50}) {
+		t.Fatalf("request sizes = %v, want [200 200 50]", sizes)
+	}
+}
+
+func TestCloudMonitoringFlushWithoutBatchSupport(t *testing.T) {
+	client := &fakeMetrics{}
+	m := newTestCloudMonitoring(t, unbatchedMetrics{client}, nil)
+	m.MessageAcked(context.Background(), "orders")
+	m.InFlight(context.Background(), "orders", 1)
+
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.requests) != 2 {
+		t.Fatalf("made %d requests, want one per series", len(client.requests))
+	}
+}
+
+func TestCloudMonitoringFlushReturnsWriteErrors(t *testing.T) {
+	errWrite := errors.New("quota exceeded")
+	client := &fakeMetrics{err: errWrite}
+	m := newTestCloudMonitoring(t, client, nil)
+	m.MessageAcked(context.Background(), "orders")
+
+	if err := m.Flush(context.Background()); !errors.Is(err, errWrite) {
+		t.Fatalf("Flush = %v, want the write error", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Package rabbitmqmetrics provides rabbitmq.Instrumentation implementations that
+// export producer and consumer metrics.
+//
+// NewOTel records OpenTelemetry instruments (including a per-queue handler latency
+// histogram) through a metric.MeterProvider. NewCloudMonitoring aggregates events in
+// memory and periodically writes them through the metrics.Client from pkg/gcp/metrics.
+//
+// Example:
+//
+//	inst, err := rabbitmqmetrics.NewOTel(meterProvider)
+//	if err != nil {
+//		panic(err)
+//	}
+//
+//	client, err := rabbitmq.NewClient(ctx, url, rabbitmq.WithInstrumentation(inst))
+//
+// Example with Cloud Monitoring:
+//
+//	metricsClient, _ := metrics.NewClient(ctx, &metrics.Cfg{ProjectID: "my-project"})
+//	inst, _ := rabbitmqmetrics.NewCloudMonitoring(ctx, metricsClient, &rabbitmqmetrics.CloudMonitoringCfg{
+//		Labels: map[string]string{"service": "billing-worker"},
+//	})
+//	defer inst.Close()
+//
+//	client, _ := rabbitmq.NewClient(ctx, url, rabbitmq.WithInstrumentation(inst))
+package rabbitmqmetrics
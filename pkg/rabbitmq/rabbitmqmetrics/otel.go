@@ -0,0 +1,180 @@
+package rabbitmqmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+const meterName = "github.com/zarvhq/zarv-go/pkg/rabbitmq"
+
+// durationBuckets are the histogram boundaries, in seconds, for publish and handler latency.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type otelInstrumentation struct {
+	published       metric.Int64Counter
+	publishDuration metric.Float64Histogram
+	consumed        metric.Int64Counter
+	processDuration metric.Float64Histogram
+	acked           metric.Int64Counter
+	nacked          metric.Int64Counter
+	inFlight        metric.Int64UpDownCounter
+}
+
+// NewOTel returns an Instrumentation that records OpenTelemetry metrics.
+// If mp is nil the global meter provider is used.
+//
+// Recorded instruments, all with the messaging.destination.name attribute:
+//   - messaging.client.sent.messages (counter)
+//   - messaging.client.operation.duration (histogram, seconds)
+//   - messaging.client.consumed.messages (counter)
+//   - messaging.process.duration (histogram, seconds)
+//   - rabbitmq.consumer.acked.messages (counter)
+//   - rabbitmq.consumer.nacked.messages (counter, with rabbitmq.requeue)
+//   - rabbitmq.consumer.inflight.messages (up-down counter)
+//
+// Failed publishes and messages also carry error.type: decode (rabbitmq.ErrDecode),
+// dead_letter (rabbitmq.ErrDeadLetter), timeout (context.DeadlineExceeded), handler
+// (any other handler error) or _OTHER (any other publish error).
+func NewOTel(mp metric.MeterProvider) (rabbitmq.Instrumentation, error) {
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(meterName)
+
+	var (
+		i   otelInstrumentation
+		err error
+	)
+
+	if i.published, err = meter.Int64Counter("messaging.client.sent.messages",
+		metric.WithDescription("Number of messages published."),
+		metric.WithUnit("{message}")); err != nil {
+		return nil, fmt.Errorf("failed to create sent messages counter: %w", err)
+	}
+	if i.publishDuration, err = meter.Float64Histogram("messaging.client.operation.duration",
+		metric.WithDescription("Duration of publish operations."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...)); err != nil {
+		return nil, fmt.Errorf("failed to create publish duration histogram: %w", err)
+	}
+	if i.consumed, err = meter.Int64Counter("messaging.client.consumed.messages",
+		metric.WithDescription("Number of messages delivered to consumers."),
+		metric.WithUnit("{message}")); err != nil {
+		return nil, fmt.Errorf("failed to create consumed messages counter: %w", err)
+	}
+	if i.processDuration, err = meter.Float64Histogram("messaging.process.duration",
+		metric.WithDescription("Duration of message handler execution."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...)); err != nil {
+		return nil, fmt.Errorf("failed to create process duration histogram: %w", err)
+	}
+	if i.acked, err = meter.Int64Counter("rabbitmq.consumer.acked.messages",
+		metric.WithDescription("Number of acknowledged messages."),
+		metric.WithUnit("{message}")); err != nil {
+		return nil, fmt.Errorf("failed to create acked messages counter: %w", err)
+	}
+	if i.nacked, err = meter.Int64Counter("rabbitmq.consumer.nacked.messages",
+		metric.WithDescription("Number of rejected messages."),
+		metric.WithUnit("{message}")); err != nil {
+		return nil, fmt.Errorf("failed to create nacked messages counter: %w", err)
+	}
+	if i.inFlight, err = meter.Int64UpDownCounter("rabbitmq.consumer.inflight.messages",
+		metric.WithDescription("Number of messages currently being processed."),
+		metric.WithUnit("{message}")); err != nil {
+		return nil, fmt.Errorf("failed to create in-flight messages counter: %w", err)
+	}
+
+	return &i, nil
+}
+
+// MessagePublished records a publish attempt.
+func (i *otelInstrumentation) MessagePublished(ctx context.Context, queueName string, _ int, duration time.Duration, err error) {
+	attrs := metric.WithAttributes(queueAttributes(queueName, publishErrorType(err))...)
+	i.published.Add(ctx, 1, attrs)
+	i.publishDuration.Record(ctx, duration.Seconds(), attrs)
+}
+
+// MessageReceived records a delivery.
+func (i *otelInstrumentation) MessageReceived(ctx context.Context, queueName string, _ int) {
+	i.consumed.Add(ctx, 1, metric.WithAttributes(queueAttributes(queueName, "")...))
+}
+
+// MessageHandled records the handler latency.
+func (i *otelInstrumentation) MessageHandled(ctx context.Context, queueName string, duration time.Duration, err error) {
+	i.processDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(queueAttributes(queueName, handleErrorType(err))...))
+}
+
+// MessageAcked records an acknowledgement.
+func (i *otelInstrumentation) MessageAcked(ctx context.Context, queueName string) {
+	i.acked.Add(ctx, 1, metric.WithAttributes(queueAttributes(queueName, "")...))
+}
+
+// MessageNacked records a rejection.
+func (i *otelInstrumentation) MessageNacked(ctx context.Context, queueName string, requeue bool) {
+	attrs := append(queueAttributes(queueName, ""), attribute.Bool("rabbitmq.requeue", requeue))
+	i.nacked.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
+
+// InFlight updates the number of messages being processed.
+func (i *otelInstrumentation) InFlight(ctx context.Context, queueName string, delta int) {
+	i.inFlight.Add(ctx, int64(delta), metric.WithAttributes(queueAttributes(queueName, "")...))
+}
+
+// error.type values. Errors are classified into a fixed set, so the attribute keeps a
+// low cardinality.
+const (
+	errorTypeDeadLetter = "dead_letter"
+	errorTypeDecode     = "decode"
+	errorTypeHandler    = "handler"
+	errorTypeTimeout    = "timeout"
+	errorTypeOther      = "_OTHER"
+)
+
+// publishErrorType classifies a publish error, returning "" for nil.
+func publishErrorType(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorTypeTimeout
+	default:
+		return errorTypeOther
+	}
+}
+
+// handleErrorType classifies the error of a handled message, returning "" for nil.
+// Decode failures are reported as such even when the message is dead-lettered.
+func handleErrorType(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, rabbitmq.ErrDecode):
+		return errorTypeDecode
+	case errors.Is(err, rabbitmq.ErrDeadLetter):
+		return errorTypeDeadLetter
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorTypeTimeout
+	default:
+		return errorTypeHandler
+	}
+}
+
+func queueAttributes(queueName, errorType string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemRabbitMQ,
+		semconv.MessagingDestinationName(queueName),
+	}
+	if errorType != "" {
+		attrs = append(attrs, semconv.ErrorTypeKey.String(errorType))
+	}
+	return attrs
+}
@@ -0,0 +1,75 @@
+package rabbitmqmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+func TestHandleErrorType(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: ""},
+		{err: fmt.Errorf("%w: %w: bad gzip", rabbitmq.ErrDecode, rabbitmq.ErrDeadLetter), want: "decode"},
+		{err: fmt.Errorf("poison: %w", rabbitmq.ErrDeadLetter), want: "dead_letter"},
+		{err: fmt.Errorf("calling api: %w", context.DeadlineExceeded), want: "timeout"},
+		{err: errors.New("boom"), want: "handler"},
+	} {
+		if got := handleErrorType(tc.err); got != tc.want {
+			t.Errorf("handleErrorType(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestPublishErrorType(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: ""},
+		{err: fmt.Errorf("%w: %w", rabbitmq.ErrConnectionBlocked, context.DeadlineExceeded), want: "timeout"},
+		{err: rabbitmq.ErrProducerClosed, want: "_OTHER"},
+	} {
+		if got := publishErrorType(tc.err); got != tc.want {
+			t.Errorf("publishErrorType(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestOTelRecordsErrorType(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	inst, err := NewOTel(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatalf("NewOTel: %v", err)
+	}
+
+	inst.MessageHandled(context.Background(), "orders", time.Millisecond, fmt.Errorf("%w: boom", rabbitmq.ErrDeadLetter))
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "messaging.process.duration" {
+				continue
+			}
+			point := m.Data.(metricdata.Histogram[float64]).DataPoints[0]
+			if got, _ := point.Attributes.Value(semconv.ErrorTypeKey); got.AsString() != "dead_letter" {
+				t.Fatalf("error.type = %q, want dead_letter", got.AsString())
+			}
+			return
+		}
+	}
+	t.Fatal("messaging.process.duration not recorded")
+}
@@ -0,0 +1,94 @@
+package rabbitmqtest
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+// InspectQueue returns the ready message and consumer counts of a queue, or
+// rabbitmq.ErrNotFound if nothing was published to or declared on it.
+func (c *Client) InspectQueue(name string) (rabbitmq.QueueInfo, error) {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[name]
+	if !ok {
+		return rabbitmq.QueueInfo{}, fmt.Errorf("inspect queue %q: %w", name, rabbitmq.ErrNotFound)
+	}
+	return rabbitmq.QueueInfo{Name: name, Messages: q.messages(), Consumers: q.consumers}, nil
+}
+
+// PurgeQueue removes the ready messages of a queue.
+func (c *Client) PurgeQueue(name string) (int, error) {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[name]
+	if !ok {
+		return 0, fmt.Errorf("purge queue %q: %w", name, rabbitmq.ErrNotFound)
+	}
+	purged := q.messages()
+	q.ready, q.log = nil, nil
+	b.broadcast()
+	return purged, nil
+}
+
+// DeleteQueue deletes a queue with its messages and history.
+func (c *Client) DeleteQueue(name string, ifUnused, ifEmpty bool) (int, error) {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[name]
+	if !ok {
+		return 0, fmt.Errorf("delete queue %q: %w", name, rabbitmq.ErrNotFound)
+	}
+	if (ifUnused && q.consumers > 0) || (ifEmpty && q.messages() > 0) {
+		return 0, fmt.Errorf("delete queue %q: %w", name, rabbitmq.ErrInUse)
+	}
+	delete(b.queues, name)
+	b.broadcast()
+	return q.messages(), nil
+}
+
+// UnbindQueue removes the binding of a shard queue to a sharded exchange, so the
+// exchange spreads keys over the remaining shards. The routing key is ignored, as shard
+// queues have a single binding. It returns rabbitmq.ErrNotFound when the queue or the
+// exchange does not exist; removing a missing binding succeeds.
+func (c *Client) UnbindQueue(name, exchange, _ string) error {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.queues[name]; !ok {
+		return fmt.Errorf("unbind queue %q: %w", name, rabbitmq.ErrNotFound)
+	}
+	bindings, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("unbind queue %q: exchange %q: %w", name, exchange, rabbitmq.ErrNotFound)
+	}
+	b.exchanges[exchange] = slices.DeleteFunc(bindings, func(queue string) bool { return queue == name })
+	return nil
+}
+
+// DeleteExchange deletes a sharded exchange; later publishes to it fail. With ifUnused,
+// it returns rabbitmq.ErrInUse while queues are still bound to the exchange.
+func (c *Client) DeleteExchange(name string, ifUnused bool) error {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bindings, ok := b.exchanges[name]
+	if !ok {
+		return fmt.Errorf("delete exchange %q: %w", name, rabbitmq.ErrNotFound)
+	}
+	if ifUnused && len(bindings) > 0 {
+		return fmt.Errorf("delete exchange %q: %w", name, rabbitmq.ErrInUse)
+	}
+	delete(b.exchanges, name)
+	return nil
+}
@@ -0,0 +1,66 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// WaitForPublished waits until at least n messages were published to queue and returns
+// them. It fails the test if that does not happen within timeout.
+func (b *Broker) WaitForPublished(t testing.TB, queueName string, n int, timeout time.Duration) []Message {
+	t.Helper()
+	b.waitFor(t, timeout, "published messages", queueName, n, func(q *queue) int { return len(q.published) })
+	return b.Published(queueName)
+}
+
+// WaitForAcked waits until at least n messages were acknowledged on queue and returns
+// them. It fails the test if that does not happen within timeout.
+func (b *Broker) WaitForAcked(t testing.TB, queueName string, n int, timeout time.Duration) []Message {
+	t.Helper()
+	b.waitFor(t, timeout, "acked messages", queueName, n, func(q *queue) int { return len(q.acked) })
+	return b.Acked(queueName)
+}
+
+// WaitForRequeued waits until at least n nacks with requeue happened on queue and
+// returns the nacked messages. It fails the test if that does not happen within timeout.
+func (b *Broker) WaitForRequeued(t testing.TB, queueName string, n int, timeout time.Duration) []Message {
+	t.Helper()
+	b.waitFor(t, timeout, "requeued messages", queueName, n, func(q *queue) int { return len(q.requeued) })
+	return b.Requeued(queueName)
+}
+
+// WaitForDeadLettered waits until at least n messages were nacked without requeue on
+// queue and returns them. It fails the test if that does not happen within timeout.
+func (b *Broker) WaitForDeadLettered(t testing.TB, queueName string, n int, timeout time.Duration) []Message {
+	t.Helper()
+	b.waitFor(t, timeout, "dead-lettered messages", queueName, n, func(q *queue) int { return len(q.deadLettered) })
+	return b.DeadLettered(queueName)
+}
+
+// WaitForIdle waits until queue has no waiting and no unacknowledged messages.
+// It fails the test if that does not happen within timeout.
+func (b *Broker) WaitForIdle(t testing.TB, queueName string, timeout time.Duration) {
+	t.Helper()
+	b.waitFor(t, timeout, "pending and unacked messages to drain", queueName, 0, func(q *queue) int {
+		return -(len(q.ready) + q.unacked)
+	})
+}
+
+// waitFor waits until count reaches n on queue, failing t after timeout.
+func (b *Broker) waitFor(t testing.TB, timeout time.Duration, what, queueName string, n int, count func(*queue) int) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var got int
+	err := b.wait(ctx, func() bool {
+		got = count(b.queue(queueName))
+		return got >= n
+	})
+	if err != nil {
+		t.Fatalf("rabbitmqtest: timed out after %s waiting for %s on queue %q (want %d, got %d)",
+			timeout, what, queueName, n, got)
+	}
+}
@@ -0,0 +1,460 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+// Message is a message held by the fake broker.
+type Message struct {
+	amqp091.Publishing
+	// Queue is the queue the message was published to.
+	Queue string
+	// Redelivered is true once the message was nacked and requeued at least once.
+	Redelivered bool
+	// Offset is the position of the message in a stream queue.
+	Offset int64
+	// PublishedAt is when the message was published.
+	PublishedAt time.Time
+}
+
+// Decode unmarshals the JSON body of the message into v.
+func (m Message) Decode(v any) error {
+	return json.Unmarshal(m.Body, v)
+}
+
+// toDelivered converts the message into what a consumer handler receives.
+func (m Message) toDelivered() *rabbitmq.Message {
+	headers := maps.Clone(map[string]any(m.Headers))
+	return &rabbitmq.Message{
+		Body:            m.Body,
+		Headers:         headers,
+		ContentType:     m.ContentType,
+		ContentEncoding: m.ContentEncoding,
+		Type:            m.Type,
+		MessageID:       m.MessageId,
+		CorrelationID:   m.CorrelationId,
+		Priority:        m.Priority,
+		Timestamp:       m.Timestamp,
+		Redelivered:     m.Redelivered,
+		RoutingKey:      m.Queue,
+	}
+}
+
+type queue struct {
+	stream       bool
+	maxPriority  uint8
+	ready        []Message
+	log          []Message
+	unacked      int
+	consumers    int
+	published    []Message
+	acked        []Message
+	requeued     []Message
+	deadLettered []Message
+}
+
+// Broker is an in-process fake of a RabbitMQ broker. Messages are routed through the
+// default exchange to the queue named by the routing key, or through sharded exchanges
+// to the shard queue selected by the hash of the routing key; queues are created on
+// first use. Clients created from the same broker share its queues.
+//
+// Nacked messages are requeued at the front of their queue with the redelivered flag
+// set. Priority queues deliver the highest priority first and stream queues keep every
+// message, so stream consumers can read them from any offset.
+//
+// The broker clock follows the wall clock, shifted by every Advance call. Delayed
+// messages are enqueued once due on that clock, so tests can release them with Advance
+// instead of sleeping.
+type Broker struct {
+	mu         sync.Mutex
+	changed    chan struct{}
+	queues     map[string]*queue
+	exchanges  map[string][]string
+	blocked    bool
+	publishErr error
+	ids        atomic.Int64
+	skew       time.Duration
+	delayed    []delayedMessage
+}
+
+// delayedMessage is a message published with a delay, waiting to become due.
+type delayedMessage struct {
+	ctx    context.Context
+	policy rabbitmq.BlockedPolicy
+	due    time.Time
+	msg    Message
+}
+
+// NewBroker creates an empty broker.
+func NewBroker() *Broker {
+	return &Broker{
+		changed:   make(chan struct{}),
+		queues:    make(map[string]*queue),
+		exchanges: make(map[string][]string),
+	}
+}
+
+// SetBlocked simulates a resource alarm: while blocked, publishes follow the client
+// blocked policy and Client.IsBlocked returns true.
+func (b *Broker) SetBlocked(blocked bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blocked = blocked
+	b.broadcast()
+}
+
+// Now returns the current time on the broker clock.
+func (b *Broker) Now() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.now()
+}
+
+// Advance moves the broker clock forward by d and enqueues, in due order, the delayed
+// messages that became due. It returns once they are enqueued; while the broker is
+// blocked, it waits like the publishes of the client that sent them.
+func (b *Broker) Advance(d time.Duration) {
+	b.mu.Lock()
+	b.skew += d
+	b.mu.Unlock()
+	b.release()
+}
+
+// Delayed returns the messages published to queue with a delay that are not due yet.
+func (b *Broker) Delayed(queueName string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var msgs []Message
+	for _, d := range b.delayed {
+		if d.msg.Queue == queueName {
+			msgs = append(msgs, d.msg)
+		}
+	}
+	return msgs
+}
+
+// SetPublishError makes every publish fail with err until it is called with nil.
+func (b *Broker) SetPublishError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.publishErr = err
+}
+
+// Published returns the messages published to queue, in order.
+func (b *Broker) Published(queueName string) []Message {
+	return b.snapshot(queueName, func(q *queue) []Message { return q.published })
+}
+
+// Pending returns the messages waiting in queue, not yet delivered to a consumer.
+// For stream queues it returns every message in the stream.
+func (b *Broker) Pending(queueName string) []Message {
+	return b.snapshot(queueName, func(q *queue) []Message {
+		if q.stream {
+			return q.log
+		}
+		return q.ready
+	})
+}
+
+// Acked returns the messages consumers acknowledged on queue.
+func (b *Broker) Acked(queueName string) []Message {
+	return b.snapshot(queueName, func(q *queue) []Message { return q.acked })
+}
+
+// Requeued returns the messages consumers nacked with requeue on queue, once per nack.
+func (b *Broker) Requeued(queueName string) []Message {
+	return b.snapshot(queueName, func(q *queue) []Message { return q.requeued })
+}
+
+// DeadLettered returns the messages consumers nacked without requeue on queue.
+func (b *Broker) DeadLettered(queueName string) []Message {
+	return b.snapshot(queueName, func(q *queue) []Message { return q.deadLettered })
+}
+
+// Unacked returns the number of messages delivered on queue and not yet settled.
+func (b *Broker) Unacked(queueName string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queue(queueName).unacked
+}
+
+func (b *Broker) snapshot(queueName string, field func(*queue) []Message) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(field(b.queue(queueName)))
+}
+
+// queue returns the named queue, creating it if needed. Must be called with b.mu held.
+func (b *Broker) queue(name string) *queue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &queue{}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// now returns the current time on the broker clock. Must be called with b.mu held.
+func (b *Broker) now() time.Time {
+	return time.Now().Add(b.skew)
+}
+
+// schedule holds msg until delay elapses on the broker clock, then publishes it with
+// policy. Publish errors at that point are dropped, as a broker would not report them.
+func (b *Broker) schedule(ctx context.Context, policy rabbitmq.BlockedPolicy, msg Message, delay time.Duration) {
+	b.mu.Lock()
+	b.delayed = append(b.delayed, delayedMessage{
+		ctx:    context.WithoutCancel(ctx),
+		policy: policy,
+		due:    b.now().Add(delay),
+		msg:    msg,
+	})
+	b.mu.Unlock()
+
+	time.AfterFunc(delay, b.release)
+}
+
+// release publishes the delayed messages that are due, in due order.
+func (b *Broker) release() {
+	b.mu.Lock()
+	now := b.now()
+	var due []delayedMessage
+	b.delayed = slices.DeleteFunc(b.delayed, func(d delayedMessage) bool {
+		if d.due.After(now) {
+			return false
+		}
+		due = append(due, d)
+		return true
+	})
+	b.mu.Unlock()
+
+	slices.SortStableFunc(due, func(a, b delayedMessage) int { return a.due.Compare(b.due) })
+	for _, d := range due {
+		_ = b.publish(d.ctx, d.policy, d.msg)
+	}
+}
+
+// broadcast wakes up everything waiting for a state change. Must be called with b.mu held.
+func (b *Broker) broadcast() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// wait blocks until cond, evaluated with b.mu held, returns true or ctx is done.
+func (b *Broker) wait(ctx context.Context, cond func() bool) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		b.mu.Lock()
+		ok, changed := cond(), b.changed
+		b.mu.Unlock()
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// declare configures a queue.
+func (b *Broker) declare(name string, configure func(*queue)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	configure(b.queue(name))
+}
+
+// publish waits out a blocked broker according to policy and enqueues msg.
+func (b *Broker) publish(ctx context.Context, policy rabbitmq.BlockedPolicy, msg Message) error {
+	b.mu.Lock()
+	blocked := b.blocked
+	b.mu.Unlock()
+
+	if blocked {
+		if policy == rabbitmq.BlockedPolicyFail {
+			return fmt.Errorf("%w: simulated alarm", rabbitmq.ErrConnectionBlocked)
+		}
+		if err := b.wait(ctx, func() bool { return !b.blocked }); err != nil {
+			return fmt.Errorf("%w: simulated alarm: %w", rabbitmq.ErrConnectionBlocked, err)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.publishErr != nil {
+		return b.publishErr
+	}
+
+	q := b.queue(msg.Queue)
+	msg.PublishedAt = b.now()
+	if msg.MessageId == "" {
+		msg.MessageId = "rabbitmqtest-" + strconv.FormatInt(b.ids.Add(1), 10)
+	}
+	if q.stream {
+		msg.Offset = int64(len(q.log))
+		q.log = append(q.log, msg)
+	} else {
+		q.ready = append(q.ready, msg)
+	}
+	q.published = append(q.published, msg)
+	b.broadcast()
+
+	return nil
+}
+
+// take removes the next message from a classic queue, waiting until one is available
+// and paused returns false, or ctx is done.
+func (b *Broker) take(ctx context.Context, queueName string, paused func() bool) (Message, bool) {
+	var msg Message
+	err := b.wait(ctx, func() bool {
+		q := b.queue(queueName)
+		if paused() || len(q.ready) == 0 {
+			return false
+		}
+
+		next := 0
+		if q.maxPriority > 0 {
+			for i := range q.ready {
+				if min(q.ready[i].Priority, q.maxPriority) > min(q.ready[next].Priority, q.maxPriority) {
+					next = i
+				}
+			}
+		}
+		msg = q.ready[next]
+		q.ready = slices.Delete(q.ready, next, next+1)
+		q.unacked++
+		return true
+	})
+	return msg, err == nil
+}
+
+// read returns the stream message at offset, waiting until it is written and paused
+// returns false, or ctx is done.
+func (b *Broker) read(ctx context.Context, queueName string, offset int64, paused func() bool) (Message, bool) {
+	var msg Message
+	err := b.wait(ctx, func() bool {
+		q := b.queue(queueName)
+		if paused() || offset >= int64(len(q.log)) {
+			return false
+		}
+		msg = q.log[offset]
+		msg.Headers = maps.Clone(msg.Headers)
+		if msg.Headers == nil {
+			msg.Headers = amqp091.Table{}
+		}
+		msg.Headers["x-stream-offset"] = msg.Offset
+		return true
+	})
+	return msg, err == nil
+}
+
+// streamStart resolves a stream offset specification against the current stream.
+func (b *Broker) streamStart(queueName string, start rabbitmq.StreamOffset) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(queueName)
+	switch v := start.Value().(type) {
+	case int64:
+		return v
+	case time.Time:
+		for _, msg := range q.log {
+			if !msg.PublishedAt.Before(v) {
+				return msg.Offset
+			}
+		}
+		return int64(len(q.log))
+	case string:
+		switch v {
+		case "first":
+			return 0
+		case "last":
+			return max(int64(len(q.log))-1, 0)
+		}
+	}
+	return int64(len(q.log))
+}
+
+// isStream reports whether the queue was declared as a stream.
+func (b *Broker) isStream(queueName string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queue(queueName).stream
+}
+
+// ack settles a delivered message.
+func (b *Broker) ack(msg Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(msg.Queue)
+	if !q.stream {
+		q.unacked--
+	}
+	q.acked = append(q.acked, msg)
+	b.broadcast()
+}
+
+// nack rejects a delivered message, putting it back at the front of the queue when requeue is true.
+func (b *Broker) nack(msg Message, requeue bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(msg.Queue)
+	if !q.stream {
+		q.unacked--
+	}
+	if !requeue {
+		q.deadLettered = append(q.deadLettered, msg)
+		b.broadcast()
+		return
+	}
+
+	q.requeued = append(q.requeued, msg)
+	if !q.stream {
+		msg.Redelivered = true
+		q.ready = slices.Insert(q.ready, 0, msg)
+	}
+	b.broadcast()
+}
+
+// notify wakes up waiters after a state change kept outside the broker, such as a
+// consumer being resumed.
+func (b *Broker) notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.broadcast()
+}
+
+// attach updates the number of consumers of a queue.
+func (b *Broker) attach(queueName string, delta int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queue(queueName).consumers += delta
+}
+
+// messages returns the number of messages ready for delivery.
+func (q *queue) messages() int {
+	if q.stream {
+		return len(q.log)
+	}
+	return len(q.ready)
+}
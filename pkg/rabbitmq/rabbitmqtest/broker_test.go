@@ -0,0 +1,359 @@
+package rabbitmqtest_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq/rabbitmqtest"
+)
+
+// handlerFunc adapts a function to rabbitmq.ConsumerHandler.
+type handlerFunc func([]byte) error
+
+func (f handlerFunc) HandleMessage(body []byte) error {
+	return f(body)
+}
+
+type order struct {
+	ID int `json:"id"`
+}
+
+// consume runs consumer in the background and stops it when the test ends.
+func consume(t *testing.T, consumer rabbitmq.Consumer, concurrency int) {
+	t.Helper()
+	go func() { _ = consumer.Consume(concurrency) }()
+	t.Cleanup(func() { _ = consumer.Stop(context.Background()) })
+}
+
+func TestPublishAndAck(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+
+	if err := producer.Publish("orders", order{ID: 42}, rabbitmq.WithType("order.created")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	msgs := broker.WaitForPublished(t, "orders", 1, time.Second)
+	var got order
+	if err := msgs[0].Decode(&got); err != nil || got.ID != 42 {
+		t.Fatalf("Decode = %+v, %v, want ID 42", got, err)
+	}
+	if msgs[0].Type != "order.created" || msgs[0].MessageId == "" {
+		t.Fatalf("published message = %+v, want a type and a message ID", msgs[0].Publishing)
+	}
+
+	consumer, _ := client.NewConsumer("worker", "orders", handlerFunc(func([]byte) error { return nil }))
+	consume(t, consumer, 2)
+
+	broker.WaitForAcked(t, "orders", 1, time.Second)
+	broker.WaitForIdle(t, "orders", time.Second)
+	if pending := broker.Pending("orders"); len(pending) != 0 {
+		t.Fatalf("pending = %d messages after ack, want 0", len(pending))
+	}
+}
+
+func TestNackRequeuesWithRedeliveredFlag(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+	_ = producer.Publish("orders", order{ID: 1})
+
+	var attempts atomic.Int32
+	consumer, _ := client.NewConsumer("worker", "orders", handlerFunc(func([]byte) error {
+		if attempts.Add(1) == 1 {
+			return errors.New("dependency down")
+		}
+		return nil
+	}))
+	consume(t, consumer, 1)
+
+	requeued := broker.WaitForRequeued(t, "orders", 1, time.Second)
+	if requeued[0].Redelivered {
+		t.Fatal("first delivery already flagged as redelivered")
+	}
+	acked := broker.WaitForAcked(t, "orders", 1, time.Second)
+	if !acked[0].Redelivered {
+		t.Fatal("requeued message not flagged as redelivered")
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+	_ = producer.Publish("orders", order{ID: 1})
+
+	consumer, _ := client.NewConsumer("worker", "orders", handlerFunc(func([]byte) error {
+		return errors.Join(errors.New("invalid order"), rabbitmq.ErrDeadLetter)
+	}))
+	consume(t, consumer, 1)
+
+	broker.WaitForDeadLettered(t, "orders", 1, time.Second)
+	broker.WaitForIdle(t, "orders", time.Second)
+	if requeued := broker.Requeued("orders"); len(requeued) != 0 {
+		t.Fatalf("requeued = %d messages, want 0", len(requeued))
+	}
+}
+
+func TestDelayedPublishWaitsForTheBrokerClock(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+
+	if err := producer.Publish("reminders", order{ID: 1}, rabbitmq.WithDelay(time.Hour)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := producer.Publish("reminders", order{ID: 2}, rabbitmq.WithDelay(time.Minute)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if pending := broker.Pending("reminders"); len(pending) != 0 {
+		t.Fatalf("pending = %d messages before the delay, want 0", len(pending))
+	}
+	if delayed := broker.Delayed("reminders"); len(delayed) != 2 {
+		t.Fatalf("delayed = %d messages, want 2", len(delayed))
+	}
+
+	before := broker.Now()
+	broker.Advance(time.Minute)
+	if got := broker.Now().Sub(before); got < time.Minute {
+		t.Fatalf("broker clock moved %s, want at least 1m", got)
+	}
+	pending := broker.Pending("reminders")
+	var got order
+	if len(pending) != 1 || pending[0].Decode(&got) != nil || got.ID != 2 {
+		t.Fatalf("pending after 1m = %+v, want order 2", pending)
+	}
+
+	broker.Advance(time.Hour)
+	if pending := broker.Pending("reminders"); len(pending) != 2 {
+		t.Fatalf("pending after 1h = %d messages, want 2", len(pending))
+	}
+	if delayed := broker.Delayed("reminders"); len(delayed) != 0 {
+		t.Fatalf("delayed = %d messages after they became due, want 0", len(delayed))
+	}
+}
+
+func TestDelayedPublishToExchangeIsRejected(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	_ = client.DeclareShardedQueues("orders", 2)
+	producer, _ := client.NewProducer()
+
+	if err := producer.PublishToExchange(context.Background(), "orders", "a", order{}, rabbitmq.WithDelay(time.Minute)); err == nil {
+		t.Fatal("delayed publish to an exchange succeeded")
+	}
+}
+
+func TestPriorityQueueDeliversHighestPriorityFirst(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	_ = client.DeclarePriorityQueue("jobs", 5)
+	producer, _ := client.NewProducer()
+	for id, priority := range []uint8{1, 9, 3} {
+		_ = producer.Publish("jobs", order{ID: id}, rabbitmq.WithPriority(priority))
+	}
+
+	consumer, _ := client.NewConsumer("worker", "jobs", handlerFunc(func([]byte) error { return nil }))
+	consume(t, consumer, 1)
+
+	acked := broker.WaitForAcked(t, "jobs", 3, time.Second)
+	want := []int{1, 2, 0} // priorities 9 (capped at 5), 3, 1
+	for i, msg := range acked {
+		var got order
+		_ = msg.Decode(&got)
+		if got.ID != want[i] {
+			t.Fatalf("delivery %d = order %d, want %d", i, got.ID, want[i])
+		}
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+
+	consumer, _ := client.NewConsumer("worker", "orders", handlerFunc(func([]byte) error { return nil }))
+	consumer.Pause()
+	if !consumer.IsPaused() {
+		t.Fatal("consumer not paused")
+	}
+	consume(t, consumer, 1)
+
+	_ = producer.Publish("orders", order{ID: 1})
+	time.Sleep(20 * time.Millisecond)
+	if acked := broker.Acked("orders"); len(acked) != 0 {
+		t.Fatalf("paused consumer acked %d messages", len(acked))
+	}
+
+	consumer.Resume()
+	broker.WaitForAcked(t, "orders", 1, time.Second)
+}
+
+func TestStreamConsumerReadsFromOffset(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	_ = client.DeclareStream("events", rabbitmq.StreamCfg{})
+	producer, _ := client.NewProducer()
+	for id := range 3 {
+		_ = producer.Publish("events", order{ID: id})
+	}
+
+	store := rabbitmq.NewMemoryOffsetStore()
+	consumer, _ := client.NewStreamConsumer("projector", "events", handlerFunc(func([]byte) error { return nil }),
+		rabbitmq.StreamConsumerCfg{Offset: rabbitmq.StreamOffsetAt(1), Store: store})
+	consume(t, consumer, 1)
+
+	acked := broker.WaitForAcked(t, "events", 2, time.Second)
+	var first order
+	_ = acked[0].Decode(&first)
+	if first.ID != 1 || acked[0].Offset != 1 {
+		t.Fatalf("first read = order %d at offset %d, want order 1 at offset 1", first.ID, acked[0].Offset)
+	}
+	if pending := broker.Pending("events"); len(pending) != 3 {
+		t.Fatalf("stream holds %d messages, want 3", len(pending))
+	}
+}
+
+func TestBatchConsumer(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+	for id := range 5 {
+		_ = producer.Publish("orders", order{ID: id})
+	}
+
+	var mu sync.Mutex
+	var sizes []int
+	consumer, _ := client.NewBatchConsumer("worker", "orders", batchHandlerFunc(func(bodies [][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(bodies))
+		return nil
+	}), 2, 10*time.Millisecond)
+	consume(t, consumer, 1)
+
+	broker.WaitForAcked(t, "orders", 5, time.Second)
+	mu.Lock()
+	defer mu.Unlock()
+	for _, size := range sizes {
+		if size > 2 {
+			t.Fatalf("batch of %d messages, want at most 2", size)
+		}
+	}
+}
+
+type batchHandlerFunc func([][]byte) error
+
+func (f batchHandlerFunc) HandleBatch(bodies [][]byte) error {
+	return f(bodies)
+}
+
+func TestBlockedBrokerFollowsPolicy(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+	broker.SetBlocked(true)
+
+	if !client.IsBlocked() {
+		t.Fatal("client not reported as blocked")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := producer.PublishWithContext(ctx, "orders", order{}); !errors.Is(err, rabbitmq.ErrConnectionBlocked) {
+		t.Fatalf("publish while blocked = %v, want ErrConnectionBlocked", err)
+	}
+
+	client.SetBlockedPolicy(rabbitmq.BlockedPolicyFail)
+	if err := producer.Publish("orders", order{}); !errors.Is(err, rabbitmq.ErrConnectionBlocked) {
+		t.Fatalf("publish with BlockedPolicyFail = %v, want ErrConnectionBlocked", err)
+	}
+
+	broker.SetBlocked(false)
+	if err := producer.Publish("orders", order{}); err != nil {
+		t.Fatalf("publish after unblocking: %v", err)
+	}
+}
+
+func TestSetPublishError(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+
+	errBroker := errors.New("simulated failure")
+	broker.SetPublishError(errBroker)
+	if err := producer.Publish("orders", order{}); !errors.Is(err, errBroker) {
+		t.Fatalf("Publish = %v, want the simulated error", err)
+	}
+	broker.SetPublishError(nil)
+	if err := producer.Publish("orders", order{}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	_ = producer.Close()
+	if err := producer.Publish("orders", order{}); !errors.Is(err, rabbitmq.ErrProducerClosed) {
+		t.Fatalf("Publish after Close = %v, want ErrProducerClosed", err)
+	}
+}
+
+func TestQueueAdmin(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	producer, _ := client.NewProducer()
+	_ = producer.Publish("orders", order{ID: 1})
+	_ = producer.Publish("orders", order{ID: 2})
+
+	info, err := client.InspectQueue("orders")
+	if err != nil || info.Messages != 2 {
+		t.Fatalf("InspectQueue = %+v, %v, want 2 messages", info, err)
+	}
+	if purged, err := client.PurgeQueue("orders"); err != nil || purged != 2 {
+		t.Fatalf("PurgeQueue = %d, %v, want 2", purged, err)
+	}
+
+	_ = producer.Publish("orders", order{ID: 3})
+	if _, err := client.DeleteQueue("orders", false, true); !errors.Is(err, rabbitmq.ErrInUse) {
+		t.Fatalf("DeleteQueue ifEmpty = %v, want ErrInUse", err)
+	}
+	if deleted, err := client.DeleteQueue("orders", false, false); err != nil || deleted != 1 {
+		t.Fatalf("DeleteQueue = %d, %v, want 1", deleted, err)
+	}
+	if _, err := client.InspectQueue("orders"); !errors.Is(err, rabbitmq.ErrNotFound) {
+		t.Fatalf("InspectQueue after delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestWaitHelpersFailOnTimeout(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+
+	recorder := &fatalRecorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		broker.WaitForPublished(recorder, "orders", 1, 10*time.Millisecond)
+	}()
+	<-done
+
+	if !recorder.failed {
+		t.Fatal("WaitForPublished did not fail the test on timeout")
+	}
+}
+
+// fatalRecorder records Fatalf calls instead of failing the test.
+type fatalRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatalf(string, ...any) {
+	r.failed = true
+	runtime.Goexit()
+}
@@ -0,0 +1,245 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+// Client is a fake rabbitmq.Client backed by a Broker.
+type Client struct {
+	broker  *Broker
+	context context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	closed  bool
+	policy  rabbitmq.BlockedPolicy
+}
+
+var _ rabbitmq.Client = (*Client)(nil)
+
+// NewClient creates a client connected to the broker. Consumers created from it stop
+// when ctx is canceled or the client is closed.
+func (b *Broker) NewClient(ctx context.Context) *Client {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Client{
+		broker:  b,
+		context: ctx,
+		cancel:  cancel,
+	}
+}
+
+// SetBlockedPolicy sets how publishes behave while the broker is blocked.
+// Defaults to rabbitmq.BlockedPolicyWait, like rabbitmq.NewClient.
+func (c *Client) SetBlockedPolicy(policy rabbitmq.BlockedPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policy = policy
+}
+
+// NewConsumer creates a consumer for a classic or priority queue.
+// Consumer options are accepted for signature compatibility and ignored.
+func (c *Client) NewConsumer(consumerName, queueName string, handler rabbitmq.ConsumerHandler, _ ...rabbitmq.ConsumerOption) (rabbitmq.Consumer, error) {
+	if handler == nil {
+		return nil, errors.New("handler cannot be nil")
+	}
+	return &consumer{base: c.newBase(consumerName, queueName), handler: handler}, nil
+}
+
+// NewBatchConsumer creates a consumer that delivers messages in batches.
+// Consumer options are accepted for signature compatibility and ignored.
+func (c *Client) NewBatchConsumer(consumerName, queueName string, handler rabbitmq.BatchHandler, batchSize int, maxWait time.Duration, _ ...rabbitmq.ConsumerOption) (rabbitmq.Consumer, error) {
+	if handler == nil {
+		return nil, errors.New("handler cannot be nil")
+	}
+	if batchSize <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
+	if maxWait <= 0 {
+		return nil, errors.New("max wait must be greater than 0")
+	}
+	return &batchConsumer{
+		base:      c.newBase(consumerName, queueName),
+		handler:   handler,
+		batchSize: batchSize,
+		maxWait:   maxWait,
+	}, nil
+}
+
+// NewStreamConsumer creates a consumer that reads a stream queue from an offset.
+// Consumer options are accepted for signature compatibility and ignored.
+func (c *Client) NewStreamConsumer(consumerName, streamName string, handler rabbitmq.ConsumerHandler, cfg rabbitmq.StreamConsumerCfg, _ ...rabbitmq.ConsumerOption) (rabbitmq.Consumer, error) {
+	if handler == nil {
+		return nil, errors.New("handler cannot be nil")
+	}
+	if cfg.CommitInterval <= 0 {
+		cfg.CommitInterval = 100
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 5 * time.Second
+	}
+	return &streamConsumer{base: c.newBase(consumerName, streamName), handler: handler, cfg: cfg}, nil
+}
+
+// NewProducer creates a producer that publishes to the broker.
+func (c *Client) NewProducer() (rabbitmq.Producer, error) {
+	if c.IsClosed() {
+		return nil, errors.New("connection is closed")
+	}
+	return &producer{client: c}, nil
+}
+
+// DeclareStream marks the queue as a stream. Retention settings are ignored.
+func (c *Client) DeclareStream(name string, _ rabbitmq.StreamCfg) error {
+	if name == "" {
+		return errors.New("stream name cannot be empty")
+	}
+	c.broker.declare(name, func(q *queue) { q.stream = true })
+	return nil
+}
+
+// DeclarePriorityQueue enables priorities up to maxPriority on the queue.
+func (c *Client) DeclarePriorityQueue(name string, maxPriority uint8) error {
+	if name == "" {
+		return errors.New("queue name cannot be empty")
+	}
+	if maxPriority == 0 {
+		return errors.New("max priority must be greater than 0")
+	}
+	c.broker.declare(name, func(q *queue) { q.maxPriority = maxPriority })
+	return nil
+}
+
+// Close closes the client and stops its consumers.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	c.cancel()
+	return nil
+}
+
+// IsClosed returns true after Close.
+func (c *Client) IsClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// IsBlocked returns true while the broker is blocked with SetBlocked.
+func (c *Client) IsBlocked() bool {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	return c.broker.blocked
+}
+
+func (c *Client) blockedPolicy() rabbitmq.BlockedPolicy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.policy
+}
+
+type producer struct {
+	client *Client
+	mu     sync.Mutex
+	closed bool
+}
+
+// Publish marshals body to JSON and publishes it to queueName.
+func (p *producer) Publish(queueName string, body any, opts ...rabbitmq.PublishOption) error {
+	return p.PublishWithContext(p.client.context, queueName, body, opts...)
+}
+
+// PublishWithContext marshals body to JSON and publishes it to queueName.
+// Delayed messages appear in the queue, and in Published, once their delay elapses on
+// the broker clock; see Broker.Advance.
+func (p *producer) PublishWithContext(ctx context.Context, queueName string, body any, opts ...rabbitmq.PublishOption) error {
+	if queueName == "" {
+		return errors.New("queue name cannot be empty")
+	}
+	return p.send(ctx, "", queueName, body, opts)
+}
+
+// PublishToExchange publishes to the shard queue of a sharded exchange selected by
+// routingKey. Only exchanges declared with DeclareShardedQueues are supported; messages
+// are dropped once every shard queue is unbound.
+func (p *producer) PublishToExchange(ctx context.Context, exchange, routingKey string, body any, opts ...rabbitmq.PublishOption) error {
+	if exchange == "" {
+		return errors.New("exchange name cannot be empty")
+	}
+	return p.send(ctx, exchange, routingKey, body, opts)
+}
+
+// send publishes to the queue named by routingKey, or to the shard of exchange it selects.
+func (p *producer) send(ctx context.Context, exchange, routingKey string, body any, opts []rabbitmq.PublishOption) error {
+	if ctx == nil {
+		return errors.New("context cannot be nil")
+	}
+	if body == nil {
+		return errors.New("message body cannot be nil")
+	}
+
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return rabbitmq.ErrProducerClosed
+	}
+	if p.client.IsClosed() {
+		return errors.New("failed to publish message: channel is closed")
+	}
+
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message body: %w", err)
+	}
+
+	msg := amqp091.Publishing{
+		ContentType:  "application/json",
+		Body:         bytes,
+		DeliveryMode: amqp091.Persistent,
+	}
+	for _, opt := range opts {
+		opt(&msg)
+	}
+
+	queueName := routingKey
+	if exchange != "" {
+		if ms, ok := msg.Headers["x-delay"].(int64); ok && ms > 0 {
+			return errors.New("delayed messages can only be published to queues")
+		}
+		var bound bool
+		if queueName, bound, err = p.client.broker.route(exchange, routingKey); err != nil || !bound {
+			return err
+		}
+	}
+
+	// Delayed messages (WithDelay, WithDeliverAt) are enqueued once due
+	if ms, ok := msg.Headers["x-delay"].(int64); ok && ms > 0 {
+		p.client.broker.schedule(ctx, p.client.blockedPolicy(), Message{Publishing: msg, Queue: queueName}, time.Duration(ms)*time.Millisecond)
+		return nil
+	}
+
+	return p.client.broker.publish(ctx, p.client.blockedPolicy(), Message{Publishing: msg, Queue: queueName})
+}
+
+// Close closes the producer; later publishes fail.
+func (p *producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Shutdown closes the producer. Publishes are confirmed synchronously, so there is
+// nothing to wait for.
+func (p *producer) Shutdown(context.Context) error {
+	return p.Close()
+}
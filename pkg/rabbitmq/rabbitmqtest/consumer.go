@@ -0,0 +1,336 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+// base implements the lifecycle shared by every fake consumer.
+type base struct {
+	broker    *Broker
+	name      string
+	queueName string
+	context   context.Context
+	stop      context.CancelFunc
+	paused    atomic.Bool
+	active    sync.WaitGroup
+}
+
+func (c *Client) newBase(consumerName, queueName string) *base {
+	ctx, stop := context.WithCancel(c.context)
+	return &base{
+		broker:    c.broker,
+		name:      consumerName,
+		queueName: queueName,
+		context:   ctx,
+		stop:      stop,
+	}
+}
+
+// Pause stops taking new messages until Resume is called.
+func (b *base) Pause() {
+	b.paused.Store(true)
+	b.broker.notify()
+}
+
+// Resume restarts consumption after Pause.
+func (b *base) Resume() {
+	b.paused.Store(false)
+	b.broker.notify()
+}
+
+// IsPaused reports whether the consumer is paused.
+func (b *base) IsPaused() bool {
+	return b.paused.Load()
+}
+
+// Stop stops the consumer and waits for Consume to return, or until ctx is done.
+func (b *base) Stop(ctx context.Context) error {
+	b.stop()
+
+	finished := make(chan struct{})
+	go func() {
+		b.active.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for consumer to stop: %w", ctx.Err())
+	}
+}
+
+// begin registers a running Consume call, unless the consumer was already stopped.
+func (b *base) begin() bool {
+	if b.context.Err() != nil {
+		return false
+	}
+	b.active.Add(1)
+	b.broker.attach(b.queueName, 1)
+	return true
+}
+
+// end unregisters a Consume call started with begin.
+func (b *base) end() {
+	b.broker.attach(b.queueName, -1)
+	b.active.Done()
+}
+
+// invoke calls handler like the real consumer does, preferring ContextHandler and
+// converting panics into errors.
+func (b *base) invoke(handler rabbitmq.ConsumerHandler, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if h, ok := handler.(rabbitmq.ContextHandler); ok {
+		return h.HandleMessageContext(b.context, msg.toDelivered())
+	}
+	return handler.HandleMessage(msg.Body)
+}
+
+// settle acks msg on success, dead-letters it on ErrDeadLetter and requeues it on
+// any other error.
+func (b *base) settle(msg Message, err error) {
+	if err == nil {
+		b.broker.ack(msg)
+		return
+	}
+	b.broker.nack(msg, !errors.Is(err, rabbitmq.ErrDeadLetter))
+}
+
+type consumer struct {
+	*base
+	handler rabbitmq.ConsumerHandler
+}
+
+// Consume delivers messages to the handler with up to concurrency messages in flight,
+// until the consumer is stopped or the client is closed.
+func (c *consumer) Consume(concurrency int) error {
+	if concurrency <= 0 {
+		return errors.New("concurrency must be greater than 0")
+	}
+	if c.broker.isStream(c.queueName) {
+		return fmt.Errorf("queue %q is a stream, use NewStreamConsumer", c.queueName)
+	}
+	if !c.begin() {
+		return nil
+	}
+	defer c.end()
+
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	for {
+		select {
+		case semaphore <- struct{}{}:
+		case <-c.context.Done():
+			return nil
+		}
+
+		msg, ok := c.broker.take(c.context, c.queueName, c.IsPaused)
+		if !ok {
+			return nil
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if len(msg.Body) == 0 {
+				c.broker.ack(msg)
+				return
+			}
+			c.settle(msg, c.invoke(c.handler, msg))
+		}()
+	}
+}
+
+type batchConsumer struct {
+	*base
+	handler   rabbitmq.BatchHandler
+	batchSize int
+	maxWait   time.Duration
+}
+
+// Consume runs concurrency batch loops until the consumer is stopped or the client is closed.
+func (c *batchConsumer) Consume(concurrency int) error {
+	if concurrency <= 0 {
+		return errors.New("concurrency must be greater than 0")
+	}
+	if !c.begin() {
+		return nil
+	}
+	defer c.end()
+
+	wg := sync.WaitGroup{}
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.consumeBatch() {
+			}
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// consumeBatch collects and handles one batch. It returns false on shutdown.
+func (c *batchConsumer) consumeBatch() bool {
+	first, ok := c.broker.take(c.context, c.queueName, c.IsPaused)
+	if !ok {
+		return false
+	}
+
+	batch := []Message{first}
+	ctx, cancel := context.WithTimeout(c.context, c.maxWait)
+	for len(batch) < c.batchSize {
+		msg, ok := c.broker.take(ctx, c.queueName, c.IsPaused)
+		if !ok {
+			break
+		}
+		batch = append(batch, msg)
+	}
+	cancel()
+
+	// Empty messages are acknowledged without reaching the handler
+	batch = slices.DeleteFunc(batch, func(msg Message) bool {
+		if len(msg.Body) == 0 {
+			c.broker.ack(msg)
+			return true
+		}
+		return false
+	})
+	if len(batch) > 0 {
+		c.handleBatch(batch)
+	}
+	return true
+}
+
+// handleBatch invokes the handler and settles the batch like the real batch consumer.
+func (c *batchConsumer) handleBatch(batch []Message) {
+	bodies := make([][]byte, len(batch))
+	for i := range batch {
+		bodies[i] = batch[i].Body
+	}
+
+	err := c.invokeBatch(bodies)
+
+	failed := make(map[int]bool, len(batch))
+	var batchErr *rabbitmq.BatchError
+	switch {
+	case err == nil:
+	case errors.As(err, &batchErr):
+		for _, i := range batchErr.Indexes {
+			failed[i] = true
+		}
+	default:
+		for i := range batch {
+			failed[i] = true
+		}
+	}
+
+	// Settle in reverse so requeued messages keep their order at the front of the queue
+	requeue := !errors.Is(err, rabbitmq.ErrDeadLetter)
+	for i := len(batch) - 1; i >= 0; i-- {
+		if failed[i] {
+			c.broker.nack(batch[i], requeue)
+		} else {
+			c.broker.ack(batch[i])
+		}
+	}
+}
+
+func (c *batchConsumer) invokeBatch(bodies [][]byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.handler.HandleBatch(bodies)
+}
+
+type streamConsumer struct {
+	*base
+	handler rabbitmq.ConsumerHandler
+	cfg     rabbitmq.StreamConsumerCfg
+}
+
+// Consume reads the stream in offset order, one message at a time, saving offsets to
+// the configured store. Handler failures are retried after RetryDelay.
+func (c *streamConsumer) Consume(prefetch int) error {
+	if prefetch <= 0 {
+		return errors.New("prefetch must be greater than 0")
+	}
+	if !c.broker.isStream(c.queueName) {
+		return fmt.Errorf("queue %q is not a stream, declare it with DeclareStream", c.queueName)
+	}
+	if !c.begin() {
+		return nil
+	}
+	defer c.end()
+
+	next := c.broker.streamStart(c.queueName, c.cfg.Offset)
+	if c.cfg.Store != nil {
+		offset, found, err := c.cfg.Store.LoadOffset(c.context, c.queueName, c.name)
+		if err != nil {
+			return fmt.Errorf("error loading stream offset: %w", err)
+		}
+		if found {
+			next = offset + 1
+		}
+	}
+
+	uncommitted := 0
+	commit := func() {
+		if c.cfg.Store != nil && uncommitted > 0 {
+			// Save errors are ignored, as the real consumer only logs them
+			_ = c.cfg.Store.SaveOffset(context.WithoutCancel(c.context), c.queueName, c.name, next-1)
+			uncommitted = 0
+		}
+	}
+	defer commit()
+
+	for {
+		msg, ok := c.broker.read(c.context, c.queueName, next, c.IsPaused)
+		if !ok {
+			return nil
+		}
+
+		var err error
+		if len(msg.Body) > 0 {
+			err = c.invoke(c.handler, msg)
+		}
+		c.broker.ack(msg)
+
+		if err != nil && !errors.Is(err, rabbitmq.ErrDeadLetter) {
+			select {
+			case <-time.After(c.cfg.RetryDelay):
+				continue
+			case <-c.context.Done():
+				return nil
+			}
+		}
+
+		next = msg.Offset + 1
+		uncommitted++
+		if uncommitted >= c.cfg.CommitInterval {
+			commit()
+		}
+	}
+}
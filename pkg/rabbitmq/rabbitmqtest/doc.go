@@ -0,0 +1,32 @@
+// Package rabbitmqtest provides an in-process fake of a RabbitMQ broker implementing
+// rabbitmq.Client, rabbitmq.Producer and rabbitmq.Consumer, so handlers and publish
+// paths can be tested without a running broker.
+//
+// The fake routes messages through the default exchange and sharded exchanges, supports
+// ack, nack with and without requeue (setting the redelivered flag), consumer
+// concurrency, batch, stream and priority queues, delayed messages, Pause/Resume/Stop
+// and simulated resource alarms. Consumer options (logging, rate limiting, circuit
+// breaker) are accepted and ignored.
+//
+// Delayed messages wait on the broker clock, which Broker.Advance moves forward, so
+// tests of long delays do not need to sleep.
+//
+// Example:
+//
+//	func TestOrderFlow(t *testing.T) {
+//		broker := rabbitmqtest.NewBroker()
+//		client := broker.NewClient(t.Context())
+//
+//		producer, _ := client.NewProducer()
+//		svc := orders.NewService(producer) // code under test
+//		svc.PlaceOrder(order)
+//
+//		msgs := broker.WaitForPublished(t, "orders", 1, time.Second)
+//		var got Order
+//		_ = msgs[0].Decode(&got)
+//
+//		consumer, _ := client.NewConsumer("worker", "orders", handler)
+//		go consumer.Consume(4)
+//		broker.WaitForAcked(t, "orders", 1, time.Second)
+//	}
+package rabbitmqtest
@@ -0,0 +1,80 @@
+package rabbitmqtest
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+)
+
+// DeclareShardedQueues declares a sharded exchange routing to shards queues named with
+// rabbitmq.ShardQueueName. Keys are spread by a plain hash of the routing key, which
+// does not match the shard the broker would pick: assert that a key always lands in the
+// same shard, not in a specific one.
+func (c *Client) DeclareShardedQueues(exchange string, shards int) error {
+	if exchange == "" {
+		return errors.New("exchange name cannot be empty")
+	}
+	if shards <= 0 {
+		return errors.New("shards must be greater than 0")
+	}
+
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	bindings := make([]string, shards)
+	for shard := range shards {
+		bindings[shard] = rabbitmq.ShardQueueName(exchange, shard)
+		c.broker.queue(bindings[shard])
+	}
+	c.broker.exchanges[exchange] = bindings
+	return nil
+}
+
+// NewShardConsumer creates a consumer for the claimed shard queues of a sharded
+// exchange. Single-active-consumer is not simulated: every consumer of a shard receives
+// its messages. Consumer options are accepted for signature compatibility and ignored.
+func (c *Client) NewShardConsumer(consumerName, exchange string, handler rabbitmq.ConsumerHandler, cfg rabbitmq.ShardConsumerCfg, _ ...rabbitmq.ConsumerOption) (rabbitmq.Consumer, error) {
+	if handler == nil {
+		return nil, errors.New("handler cannot be nil")
+	}
+	if cfg.Shards <= 0 {
+		return nil, errors.New("shards must be greater than 0")
+	}
+	shards := cfg.Claim
+	if len(shards) == 0 {
+		shards = make([]int, cfg.Shards)
+		for i := range shards {
+			shards[i] = i
+		}
+	}
+
+	consumers := make([]rabbitmq.Consumer, len(shards))
+	for i, shard := range shards {
+		if shard < 0 || shard >= cfg.Shards {
+			return nil, fmt.Errorf("claimed shard %d is out of range [0, %d)", shard, cfg.Shards)
+		}
+		name := rabbitmq.ShardQueueName(exchange, shard)
+		consumers[i] = &consumer{base: c.newBase(fmt.Sprintf("%s-%d", consumerName, shard), name), handler: handler}
+	}
+	return rabbitmq.NewShardGroup(consumers...), nil
+}
+
+// route returns the shard queue of a sharded exchange for routingKey, picked among the
+// queues still bound to it. It returns false when no queue is bound, as the broker
+// drops the message.
+func (b *Broker) route(exchange, routingKey string) (string, bool, error) {
+	b.mu.Lock()
+	bindings, ok := b.exchanges[exchange]
+	b.mu.Unlock()
+	if !ok {
+		return "", false, fmt.Errorf("failed to publish message: exchange %q not found", exchange)
+	}
+	if len(bindings) == 0 {
+		return "", false, nil
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(routingKey))
+	return bindings[h.Sum32()%uint32(len(bindings))], true, nil
+}
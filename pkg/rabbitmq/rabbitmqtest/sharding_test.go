@@ -0,0 +1,243 @@
+package rabbitmqtest_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq/rabbitmqtest"
+)
+
+// shardOf publishes a message with key to exchange and returns the shard queue it landed in.
+func shardOf(t *testing.T, broker *rabbitmqtest.Broker, producer rabbitmq.Producer, exchange string, shards int, key string) string {
+	t.Helper()
+	before := make([]int, shards)
+	for shard := range shards {
+		before[shard] = len(broker.Published(rabbitmq.ShardQueueName(exchange, shard)))
+	}
+	if err := producer.PublishToExchange(context.Background(), exchange, key, map[string]string{"key": key}); err != nil {
+		t.Fatalf("PublishToExchange: %v", err)
+	}
+	for shard := range shards {
+		name := rabbitmq.ShardQueueName(exchange, shard)
+		if len(broker.Published(name)) > before[shard] {
+			return name
+		}
+	}
+	return ""
+}
+
+func TestShardedExchangeRoutesKeysConsistently(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	if err := client.DeclareShardedQueues("orders", 4); err != nil {
+		t.Fatalf("DeclareShardedQueues: %v", err)
+	}
+	producer, _ := client.NewProducer()
+
+	for _, key := range []string{"customer-1", "customer-2", "customer-3"} {
+		first := shardOf(t, broker, producer, "orders", 4, key)
+		if first == "" {
+			t.Fatalf("message with key %s not routed", key)
+		}
+		if again := shardOf(t, broker, producer, "orders", 4, key); again != first {
+			t.Fatalf("key %s routed to %s, then %s", key, first, again)
+		}
+	}
+}
+
+func TestShardConsumerHandlesClaimedShards(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	if err := client.DeclareShardedQueues("orders", 2); err != nil {
+		t.Fatalf("DeclareShardedQueues: %v", err)
+	}
+	producer, _ := client.NewProducer()
+
+	var mu sync.Mutex
+	handled := 0
+	consumer, err := client.NewShardConsumer("worker", "orders", handlerFunc(func([]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return nil
+	}), rabbitmq.ShardConsumerCfg{Shards: 2})
+	if err != nil {
+		t.Fatalf("NewShardConsumer: %v", err)
+	}
+	go func() { _ = consumer.Consume(1) }()
+	t.Cleanup(func() { _ = consumer.Stop(context.Background()) })
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		if err := producer.PublishToExchange(context.Background(), "orders", key, map[string]string{"key": key}); err != nil {
+			t.Fatalf("PublishToExchange: %v", err)
+		}
+	}
+	for shard := range 2 {
+		broker.WaitForIdle(t, rabbitmq.ShardQueueName("orders", shard), time.Second)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if handled != 5 {
+		t.Fatalf("handled %d messages, want 5", handled)
+	}
+
+	if _, err := client.NewShardConsumer("worker", "orders", handlerFunc(func([]byte) error { return nil }), rabbitmq.ShardConsumerCfg{Shards: 2, Claim: []int{2}}); err == nil {
+		t.Fatal("NewShardConsumer accepted an out-of-range claim")
+	}
+}
+
+func TestUnbindQueueSpreadsKeysOverRemainingShards(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	if err := client.DeclareShardedQueues("orders", 2); err != nil {
+		t.Fatalf("DeclareShardedQueues: %v", err)
+	}
+	producer, _ := client.NewProducer()
+
+	removed := rabbitmq.ShardQueueName("orders", 0)
+	if err := client.UnbindQueue(removed, "orders", "1"); err != nil {
+		t.Fatalf("UnbindQueue: %v", err)
+	}
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		if got := shardOf(t, broker, producer, "orders", 2, key); got != rabbitmq.ShardQueueName("orders", 1) {
+			t.Fatalf("key %s routed to %q after unbinding %s", key, got, removed)
+		}
+	}
+
+	if err := client.UnbindQueue(rabbitmq.ShardQueueName("orders", 1), "orders", "1"); err != nil {
+		t.Fatalf("UnbindQueue: %v", err)
+	}
+	if got := shardOf(t, broker, producer, "orders", 2, "a"); got != "" {
+		t.Fatalf("message routed to %s with no queue bound", got)
+	}
+
+	if err := client.UnbindQueue("missing", "orders", ""); !errors.Is(err, rabbitmq.ErrNotFound) {
+		t.Fatalf("UnbindQueue of a missing queue = %v, want ErrNotFound", err)
+	}
+	if err := client.UnbindQueue(removed, "missing", ""); !errors.Is(err, rabbitmq.ErrNotFound) {
+		t.Fatalf("UnbindQueue from a missing exchange = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteExchange(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	if err := client.DeclareShardedQueues("orders", 1); err != nil {
+		t.Fatalf("DeclareShardedQueues: %v", err)
+	}
+	producer, _ := client.NewProducer()
+
+	if err := client.DeleteExchange("orders", true); !errors.Is(err, rabbitmq.ErrInUse) {
+		t.Fatalf("DeleteExchange with bindings and ifUnused = %v, want ErrInUse", err)
+	}
+	if err := client.DeleteExchange("orders", false); err != nil {
+		t.Fatalf("DeleteExchange: %v", err)
+	}
+	if err := producer.PublishToExchange(context.Background(), "orders", "a", map[string]string{}); err == nil {
+		t.Fatal("publish to a deleted exchange succeeded")
+	}
+	if err := client.DeleteExchange("orders", false); !errors.Is(err, rabbitmq.ErrNotFound) {
+		t.Fatalf("DeleteExchange of a deleted exchange = %v, want ErrNotFound", err)
+	}
+}
+
+// failingShard fails its first Consume call, as a shard consumer whose channel
+// closes does, and counts the calls.
+type failingShard struct {
+	rabbitmq.Consumer
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *failingShard) Consume(concurrency int) error {
+	c.mu.Lock()
+	c.calls++
+	first := c.calls == 1
+	c.mu.Unlock()
+
+	if first {
+		return errors.New("channel closed")
+	}
+	return c.Consumer.Consume(concurrency)
+}
+
+func TestRouterRestartsFailedShard(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	if err := client.DeclareShardedQueues("orders", 2); err != nil {
+		t.Fatalf("DeclareShardedQueues: %v", err)
+	}
+	ack := handlerFunc(func([]byte) error { return nil })
+	healthy, _ := client.NewConsumer("worker-0", rabbitmq.ShardQueueName("orders", 0), ack)
+	inner, _ := client.NewConsumer("worker-1", rabbitmq.ShardQueueName("orders", 1), ack)
+	failing := &failingShard{Consumer: inner}
+
+	router := rabbitmq.NewRouter(client,
+		rabbitmq.WithRouterLogger(slog.New(slog.DiscardHandler)),
+		rabbitmq.WithRestartBackoff(time.Millisecond, time.Millisecond))
+	if err := router.HandleConsumer("orders", rabbitmq.NewShardGroup(healthy, failing), 1); err != nil {
+		t.Fatalf("HandleConsumer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	result := make(chan error, 1)
+	go func() { result <- router.Run(ctx) }()
+
+	producer, _ := client.NewProducer()
+	for shard := range 2 {
+		if err := producer.Publish(rabbitmq.ShardQueueName("orders", shard), map[string]int{"shard": shard}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	for shard := range 2 {
+		broker.WaitForAcked(t, rabbitmq.ShardQueueName("orders", shard), 1, 2*time.Second)
+	}
+
+	cancel()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("Run = %v, want nil after cancellation", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("router did not stop")
+	}
+
+	failing.mu.Lock()
+	defer failing.mu.Unlock()
+	if failing.calls != 2 {
+		t.Fatalf("failed shard consumed %d times, want 1 failure and 1 restart", failing.calls)
+	}
+}
+
+func TestShardGroupConsumeReturnsFirstShardError(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	ack := handlerFunc(func([]byte) error { return nil })
+	healthy, _ := client.NewConsumer("worker-0", "orders.0", ack)
+	inner, _ := client.NewConsumer("worker-1", "orders.1", ack)
+	group := rabbitmq.NewShardGroup(healthy, &failingShard{Consumer: inner})
+	t.Cleanup(func() { _ = group.Stop(context.Background()) })
+
+	returned := make(chan error, 1)
+	go func() { returned <- group.Consume(1) }()
+	select {
+	case err := <-returned:
+		if err == nil || !strings.Contains(err.Error(), "shard consumer 1: channel closed") {
+			t.Fatalf("Consume = %v, want the failed shard's error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Consume still blocked after a shard failed")
+	}
+
+	// The healthy shard kept running while the group was down.
+	producer, _ := client.NewProducer()
+	_ = producer.Publish("orders.0", map[string]int{"id": 1})
+	broker.WaitForAcked(t, "orders.0", 1, time.Second)
+}
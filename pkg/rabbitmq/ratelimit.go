@@ -0,0 +1,56 @@
+package rabbitmq
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter limits how fast a consumer takes deliveries for processing.
+// While the limiter blocks, deliveries stay unacknowledged in the prefetch buffer,
+// so the broker stops sending more instead of messages being nacked.
+//
+// A *rate.Limiter from golang.org/x/time/rate satisfies this interface. Passing the
+// same limiter to several consumers enforces a quota shared between them, and custom
+// implementations can coordinate quotas across processes.
+type RateLimiter interface {
+	// Wait blocks until a message may be processed or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// NewRateLimiter returns a token-bucket RateLimiter that allows perSecond messages per
+// second on average, with bursts of up to burst messages. A perSecond of zero or less
+// disables the limit, rather than letting Wait fail once the burst is spent, which
+// would make the consumer requeue every delivery straight back to the broker.
+func NewRateLimiter(perSecond float64, burst int) RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	limit := rate.Limit(perSecond)
+	if perSecond <= 0 {
+		limit = rate.Inf
+	}
+	return rate.NewLimiter(limit, burst)
+}
+
+// waitRateLimit blocks until the consumer rate limiter allows another message, or
+// until the subscription cycle ends because done or interrupt is closed (Stop, channel
+// close, Pause or a circuit breaker transition).
+func (c *consumer) waitRateLimit(done, interrupt <-chan struct{}) error {
+	if c.rateLimiter == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(c.stopCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+		case <-interrupt:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+
+	return c.rateLimiter.Wait(ctx)
+}
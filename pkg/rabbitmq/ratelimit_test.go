@@ -0,0 +1,80 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newLimitedConsumer returns a consumer whose rate limiter has no tokens left.
+func newLimitedConsumer(t *testing.T) *consumer {
+	t.Helper()
+	limiter := NewRateLimiter(0.001, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("taking the only token: %v", err)
+	}
+	return newTestClient().newConsumer("orders", "orders", testHandler(func([]byte) error { return nil }), []ConsumerOption{
+		WithRateLimiter(limiter),
+	})
+}
+
+func TestWaitRateLimitEndsWithTheCycle(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		close func(c *consumer, done, interrupt chan struct{})
+	}{
+		{name: "interrupt", close: func(_ *consumer, _, interrupt chan struct{}) { close(interrupt) }},
+		{name: "done", close: func(_ *consumer, done, _ chan struct{}) { close(done) }},
+		{name: "stop", close: func(c *consumer, _, _ chan struct{}) { c.stop() }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newLimitedConsumer(t)
+			done, interrupt := make(chan struct{}), make(chan struct{})
+
+			result := make(chan error, 1)
+			go func() { result <- c.waitRateLimit(done, interrupt) }()
+			select {
+			case err := <-result:
+				t.Fatalf("waitRateLimit returned %v before the cycle ended", err)
+			case <-time.After(20 * time.Millisecond):
+			}
+			tc.close(c, done, interrupt)
+
+			select {
+			case err := <-result:
+				if err == nil {
+					t.Fatal("waitRateLimit allowed a message without tokens")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("waitRateLimit kept blocking after the cycle ended")
+			}
+		})
+	}
+}
+
+func TestWaitRateLimitWithoutLimiter(t *testing.T) {
+	c := newTestClient().newConsumer("orders", "orders", testHandler(func([]byte) error { return nil }), nil)
+	if err := c.waitRateLimit(nil, nil); err != nil {
+		t.Fatalf("waitRateLimit without a limiter: %v", err)
+	}
+}
+
+func TestNewRateLimiterWithoutPositiveRateIsUnlimited(t *testing.T) {
+	for _, perSecond := range []float64{0, -5} {
+		limiter := NewRateLimiter(perSecond, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		for i := range 100 {
+			if err := limiter.Wait(ctx); err != nil {
+				t.Fatalf("NewRateLimiter(%v, 1): Wait #%d = %v, want no limit", perSecond, i+1, err)
+			}
+		}
+		cancel()
+	}
+}
+
+func TestNewRateLimiterClampsBurst(t *testing.T) {
+	limiter := NewRateLimiter(1, 0)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait with a clamped burst: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	defaultRestartDelay    = time.Second
+	defaultMaxRestartDelay = 30 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// Router runs the consumers of several queues over a shared Client: it starts every
+// registered consumer, restarts the ones that fail with exponential backoff and, when
+// the context given to Run is canceled, stops them all and waits for in-flight messages.
+type Router struct {
+	client          Client
+	logger          *slog.Logger
+	restartDelay    time.Duration
+	maxRestartDelay time.Duration
+	shutdownTimeout time.Duration
+	mu              sync.Mutex
+	routes          []*route
+	running         bool
+}
+
+type route struct {
+	name        string
+	consumer    Consumer
+	concurrency int
+}
+
+// NewRouter creates a router whose consumers are created from client.
+func NewRouter(client Client, opts ...RouterOption) *Router {
+	r := &Router{
+		client:          client,
+		logger:          slog.Default(),
+		restartDelay:    defaultRestartDelay,
+		maxRestartDelay: defaultMaxRestartDelay,
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Handle registers a consumer for queueName that processes up to concurrency messages
+// at once. The consumer is named after the queue.
+func (r *Router) Handle(queueName string, handler ConsumerHandler, concurrency int, opts ...ConsumerOption) error {
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+	consumer, err := r.client.NewConsumer(queueName, queueName, handler, opts...)
+	if err != nil {
+		return err
+	}
+	return r.HandleConsumer(queueName, consumer, concurrency)
+}
+
+// HandleBatch registers a batch consumer for queueName with concurrency batch loops.
+// The consumer is named after the queue.
+func (r *Router) HandleBatch(queueName string, handler BatchHandler, batchSize int, maxWait time.Duration, concurrency int, opts ...ConsumerOption) error {
+	consumer, err := r.client.NewBatchConsumer(queueName, queueName, handler, batchSize, maxWait, opts...)
+	if err != nil {
+		return err
+	}
+	return r.HandleConsumer(queueName, consumer, concurrency)
+}
+
+// HandleConsumer registers an existing consumer, such as a stream consumer, under name.
+// concurrency is passed to Consume.
+func (r *Router) HandleConsumer(name string, consumer Consumer, concurrency int) error {
+	if name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if consumer == nil {
+		return fmt.Errorf("consumer cannot be nil")
+	}
+	if concurrency <= 0 {
+		return fmt.Errorf("concurrency must be greater than 0")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return fmt.Errorf("cannot register %q: router is running", name)
+	}
+	for _, rt := range r.routes {
+		if rt.name == name {
+			return fmt.Errorf("consumer %q is already registered", name)
+		}
+	}
+	r.routes = append(r.routes, &route{name: name, consumer: consumer, concurrency: concurrency})
+
+	return nil
+}
+
+// Consumers returns the registered consumers by name, e.g. for NewControlHandler.
+func (r *Router) Consumers() map[string]Consumer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	consumers := make(map[string]Consumer, len(r.routes))
+	for _, rt := range r.routes {
+		consumers[rt.name] = rt.consumer
+	}
+	return consumers
+}
+
+// Run starts every registered consumer and blocks until ctx is canceled or the client
+// connection closes. It then stops all consumers, waiting up to the shutdown timeout
+// for in-flight messages, and returns the combined errors of every consumer.
+// A router can only run once.
+func (r *Router) Run(ctx context.Context) error {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return fmt.Errorf("router is already running")
+	}
+	if len(r.routes) == 0 {
+		r.mu.Unlock()
+		return fmt.Errorf("no consumers registered")
+	}
+	r.running = true
+	routes := slices.Clone(r.routes)
+	r.mu.Unlock()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var errsMu sync.Mutex
+	var errs []error
+	addErr := func(err error) {
+		errsMu.Lock()
+		defer errsMu.Unlock()
+		errs = append(errs, err)
+	}
+
+	finished := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for _, rt := range routes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.runRoute(runCtx, rt); err != nil {
+				addErr(err)
+				cancel() // a fatal error shuts the whole router down
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	r.logger.Info("router started", slog.Int("consumers", len(routes)))
+	<-runCtx.Done()
+	r.logger.Info("router stopping")
+
+	stopCtx, stopCancel := context.WithTimeout(context.WithoutCancel(ctx), r.shutdownTimeout)
+	defer stopCancel()
+
+	stopWg := sync.WaitGroup{}
+	for _, rt := range routes {
+		stopWg.Add(1)
+		go func() {
+			defer stopWg.Done()
+			if err := rt.consumer.Stop(stopCtx); err != nil {
+				addErr(fmt.Errorf("consumer %q: %w", rt.name, err))
+			}
+		}()
+	}
+	stopWg.Wait()
+
+	select {
+	case <-finished:
+	case <-stopCtx.Done():
+		addErr(fmt.Errorf("timed out waiting for consumers to stop: %w", stopCtx.Err()))
+	}
+
+	r.logger.Info("router stopped")
+
+	errsMu.Lock()
+	defer errsMu.Unlock()
+	return errors.Join(errs...)
+}
+
+// runRoute runs a consumer until ctx is canceled, restarting it with exponential
+// backoff when Consume returns. It returns an error only when the consumer cannot be
+// restarted because the client connection is closed.
+func (r *Router) runRoute(ctx context.Context, rt *route) error {
+	logger := r.logger.With(slog.String("handler", rt.name))
+	delay := r.restartDelay
+
+	for {
+		started := time.Now()
+		err := rt.consumer.Consume(rt.concurrency)
+		if ctx.Err() != nil {
+			return err
+		}
+		if r.client.IsClosed() {
+			if err == nil {
+				err = amqp091.ErrClosed
+			}
+			return fmt.Errorf("consumer %q: connection closed: %w", rt.name, err)
+		}
+
+		// A consumer that ran for a while failed for a new reason: start over from the initial delay
+		if time.Since(started) > r.maxRestartDelay {
+			delay = r.restartDelay
+		}
+
+		attrs := []any{slog.Duration("delay", delay)}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.Warn("consumer exited, restarting", attrs...)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		delay = min(delay*2, r.maxRestartDelay)
+	}
+}
@@ -0,0 +1,207 @@
+package rabbitmq_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq"
+	"github.com/zarvhq/zarv-go/pkg/rabbitmq/rabbitmqtest"
+)
+
+// handlerFunc adapts a function to rabbitmq.ConsumerHandler.
+type handlerFunc func([]byte) error
+
+func (f handlerFunc) HandleMessage(body []byte) error {
+	return f(body)
+}
+
+var ack = handlerFunc(func([]byte) error { return nil })
+
+// flakyConsumer fails its first failures Consume calls immediately, as a consumer
+// whose channel closes does, and records when each call started.
+type flakyConsumer struct {
+	rabbitmq.Consumer
+	mu       sync.Mutex
+	failures int
+	starts   []time.Time
+}
+
+func (c *flakyConsumer) Consume(concurrency int) error {
+	c.mu.Lock()
+	c.starts = append(c.starts, time.Now())
+	fail := len(c.starts) <= c.failures
+	c.mu.Unlock()
+
+	if fail {
+		return errors.New("channel closed")
+	}
+	return c.Consumer.Consume(concurrency)
+}
+
+func newTestRouter(client rabbitmq.Client, opts ...rabbitmq.RouterOption) *rabbitmq.Router {
+	return rabbitmq.NewRouter(client, append([]rabbitmq.RouterOption{
+		rabbitmq.WithRouterLogger(slog.New(slog.DiscardHandler)),
+	}, opts...)...)
+}
+
+// runRouter runs router in the background and returns the channel Run's result is sent to.
+func runRouter(ctx context.Context, router *rabbitmq.Router) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- router.Run(ctx) }()
+	return result
+}
+
+func waitResult(t *testing.T, result <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("router did not stop")
+		return nil
+	}
+}
+
+func TestRouterRestartsExitedConsumerWithBackoff(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	inner, _ := client.NewConsumer("orders", "orders", ack)
+	consumer := &flakyConsumer{Consumer: inner, failures: 3}
+
+	router := newTestRouter(client, rabbitmq.WithRestartBackoff(10*time.Millisecond, 40*time.Millisecond))
+	if err := router.HandleConsumer("orders", consumer, 1); err != nil {
+		t.Fatalf("HandleConsumer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	result := runRouter(ctx, router)
+
+	producer, _ := client.NewProducer()
+	_ = producer.Publish("orders", map[string]int{"id": 1})
+	broker.WaitForAcked(t, "orders", 1, 2*time.Second)
+
+	cancel()
+	if err := waitResult(t, result); err != nil {
+		t.Fatalf("Run = %v, want nil after cancellation", err)
+	}
+
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+	if len(consumer.starts) != 4 {
+		t.Fatalf("Consume called %d times, want 3 failures and 1 restart that stuck", len(consumer.starts))
+	}
+	for i, want := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond} {
+		if gap := consumer.starts[i+1].Sub(consumer.starts[i]); gap < want {
+			t.Errorf("restart %d after %s, want at least %s", i+1, gap, want)
+		}
+	}
+}
+
+func TestRouterStopsWhenClientCloses(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	router := newTestRouter(client, rabbitmq.WithRestartBackoff(time.Millisecond, time.Millisecond))
+	if err := router.Handle("orders", ack, 1); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := router.Handle("payments", ack, 1); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	result := runRouter(t.Context(), router)
+
+	time.Sleep(20 * time.Millisecond)
+	_ = client.Close()
+
+	if err := waitResult(t, result); !errors.Is(err, amqp091.ErrClosed) {
+		t.Fatalf("Run = %v, want a fatal error wrapping amqp091.ErrClosed", err)
+	}
+}
+
+func TestRouterShutdownTimeout(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	client := broker.NewClient(t.Context())
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	router := newTestRouter(client, rabbitmq.WithShutdownTimeout(50*time.Millisecond))
+	handling := make(chan struct{}, 1)
+	if err := router.Handle("orders", handlerFunc(func([]byte) error {
+		handling <- struct{}{}
+		<-release
+		return nil
+	}), 1); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	result := runRouter(ctx, router)
+
+	producer, _ := client.NewProducer()
+	_ = producer.Publish("orders", map[string]int{"id": 1})
+	<-handling
+
+	stopping := time.Now()
+	cancel()
+	err := waitResult(t, result)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run = %v, want a shutdown timeout", err)
+	}
+	if elapsed := time.Since(stopping); elapsed > time.Second {
+		t.Fatalf("Run returned after %s, want about the 50ms shutdown timeout", elapsed)
+	}
+}
+
+func TestRouterRunWithoutConsumers(t *testing.T) {
+	client := rabbitmqtest.NewBroker().NewClient(t.Context())
+	router := newTestRouter(client)
+
+	if err := router.Run(t.Context()); err == nil {
+		t.Fatal("Run without consumers succeeded")
+	}
+
+	// The failed Run must leave the router usable.
+	if err := router.Handle("orders", ack, 1); err != nil {
+		t.Fatalf("Handle after a failed Run: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	result := runRouter(ctx, router)
+	cancel()
+	if err := waitResult(t, result); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestRouterRejectsDuplicateAndLateRegistrations(t *testing.T) {
+	client := rabbitmqtest.NewBroker().NewClient(t.Context())
+	router := newTestRouter(client)
+
+	if err := router.Handle("orders", ack, 1); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := router.Handle("orders", ack, 1); err == nil {
+		t.Fatal("registered the same queue twice")
+	}
+	if err := router.Handle("payments", ack, 0); err == nil {
+		t.Fatal("registered a consumer without concurrency")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	result := runRouter(ctx, router)
+	time.Sleep(10 * time.Millisecond)
+	if err := router.Handle("payments", ack, 1); err == nil {
+		t.Fatal("registered a consumer while running")
+	}
+	if err := router.Run(ctx); err == nil {
+		t.Fatal("ran the router twice")
+	}
+	cancel()
+	_ = waitResult(t, result)
+
+	if got := router.Consumers(); len(got) != 1 || got["orders"] == nil {
+		t.Fatalf("Consumers = %v, want only orders", got)
+	}
+}
@@ -0,0 +1,184 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var (
+	// ErrSchemaNotFound is returned by a SchemaRegistry for message types and versions
+	// it has no schema for.
+	ErrSchemaNotFound = errors.New("schema not found")
+	// ErrInvalidMessage is returned when a message body does not match its schema.
+	ErrInvalidMessage = errors.New("invalid message")
+)
+
+const (
+	// headerSchemaVersion carries the schema version of a message, set with WithSchemaVersion.
+	headerSchemaVersion = "x-schema-version"
+
+	defaultSchemaVersion = "v1"
+
+	// schemaURLPrefix roots the schema files of an FSSchemaRegistry, so $ref between
+	// them resolves relative to the file system.
+	schemaURLPrefix = "file:///"
+)
+
+// SchemaRegistry validates message bodies against the schema of their type and version.
+// See FSSchemaRegistry for an implementation reading JSON Schema files.
+type SchemaRegistry interface {
+	// Validate checks body against the schema of msgType at version. It returns an
+	// error wrapping ErrSchemaNotFound when there is no such schema, and one wrapping
+	// ErrInvalidMessage when body does not match it.
+	Validate(ctx context.Context, msgType, version string, body []byte) error
+}
+
+// FSSchemaRegistry validates messages with JSON Schema files laid out as
+// <type>/<version>.json, e.g. order.created/v1.json. Schemas may reference other
+// files of the file system with relative $ref.
+type FSSchemaRegistry struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+var _ SchemaRegistry = (*FSSchemaRegistry)(nil)
+
+// NewFSSchemaRegistry compiles every <type>/<version>.json schema in fsys, so invalid
+// schemas are reported at startup. Use os.DirFS for a directory or embed.FS to ship the
+// schemas with the binary.
+func NewFSSchemaRegistry(fsys fs.FS) (*FSSchemaRegistry, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(fsLoader{fsys: fsys})
+
+	files, err := fs.Glob(fsys, "*/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema files: %w", err)
+	}
+
+	r := &FSSchemaRegistry{schemas: make(map[string]*jsonschema.Schema, len(files))}
+	for _, file := range files {
+		schema, err := compiler.Compile(schemaURLPrefix + file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile schema %s: %w", file, err)
+		}
+		r.schemas[strings.TrimSuffix(file, ".json")] = schema
+	}
+
+	return r, nil
+}
+
+// Validate implements SchemaRegistry.
+func (r *FSSchemaRegistry) Validate(_ context.Context, msgType, version string, body []byte) error {
+	schema, ok := r.schemas[msgType+"/"+version]
+	if !ok {
+		return fmt.Errorf("%w: %s %s", ErrSchemaNotFound, msgType, version)
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %s %s: body is not valid JSON: %w", ErrInvalidMessage, msgType, version, err)
+	}
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("%w: %s %s: %w", ErrInvalidMessage, msgType, version, err)
+	}
+
+	return nil
+}
+
+// fsLoader loads schema files referenced by URL from a file system.
+type fsLoader struct {
+	fsys fs.FS
+}
+
+func (l fsLoader) Load(url string) (any, error) {
+	name, ok := strings.CutPrefix(url, schemaURLPrefix)
+	if !ok {
+		return nil, fmt.Errorf("schema %q is outside the registry", url)
+	}
+	f, err := l.fsys.Open(path.Clean(name))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return jsonschema.UnmarshalJSON(f)
+}
+
+// SchemaCfg configures the validation of message contracts by WithSchemaValidation.
+// Messages are matched to a schema by their AMQP type property (WithType) and the
+// version set with WithSchemaVersion.
+type SchemaCfg struct {
+	// Registry holds the schemas. Required.
+	Registry SchemaRegistry
+	// DefaultVersion is the schema version of messages published without
+	// WithSchemaVersion. Defaults to "v1".
+	DefaultVersion string
+	// Strict rejects messages without a type or whose type and version have no schema.
+	// By default they are published and consumed without validation.
+	Strict bool
+}
+
+type schemaValidator struct {
+	cfg SchemaCfg
+}
+
+func newSchemaValidator(cfg SchemaCfg) *schemaValidator {
+	if cfg.DefaultVersion == "" {
+		cfg.DefaultVersion = defaultSchemaVersion
+	}
+	return &schemaValidator{cfg: cfg}
+}
+
+// validatePublishing checks the body of msg, before it is compressed or encrypted.
+func (v *schemaValidator) validatePublishing(ctx context.Context, msg *amqp091.Publishing) error {
+	if v == nil {
+		return nil
+	}
+	return v.validate(ctx, msg.Type, msg.Headers, msg.Body)
+}
+
+// validateDelivery checks the decoded body of msg. Messages that do not match their
+// schema return an error wrapping ErrDeadLetter; other registry failures are returned
+// as is, so the message is requeued.
+func (v *schemaValidator) validateDelivery(ctx context.Context, msg *amqp091.Delivery) error {
+	if v == nil {
+		return nil
+	}
+	err := v.validate(ctx, msg.Type, msg.Headers, msg.Body)
+	if errors.Is(err, ErrInvalidMessage) {
+		return fmt.Errorf("%w: %w", ErrDeadLetter, err)
+	}
+	return err
+}
+
+func (v *schemaValidator) validate(ctx context.Context, msgType string, headers amqp091.Table, body []byte) error {
+	if v.cfg.Registry == nil {
+		return nil
+	}
+	if msgType == "" {
+		if v.cfg.Strict {
+			return fmt.Errorf("%w: message has no type", ErrInvalidMessage)
+		}
+		return nil
+	}
+
+	version, _ := headers[headerSchemaVersion].(string)
+	if version == "" {
+		version = v.cfg.DefaultVersion
+	}
+
+	err := v.cfg.Registry.Validate(ctx, msgType, version, body)
+	if errors.Is(err, ErrSchemaNotFound) {
+		if v.cfg.Strict {
+			return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+		}
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var testSchemas = fstest.MapFS{
+	"common/money.json": {Data: []byte(`{
+		"type": "object",
+		"required": ["amount", "currency"],
+		"properties": {
+			"amount": {"type": "integer", "minimum": 0},
+			"currency": {"type": "string", "minLength": 3, "maxLength": 3}
+		}
+	}`)},
+	"order.created/v1.json": {Data: []byte(`{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"id": {"type": "string"}}
+	}`)},
+	"order.created/v2.json": {Data: []byte(`{
+		"type": "object",
+		"required": ["id", "total"],
+		"properties": {
+			"id": {"type": "string"},
+			"total": {"$ref": "../common/money.json"}
+		}
+	}`)},
+}
+
+func TestFSSchemaRegistry(t *testing.T) {
+	registry, err := NewFSSchemaRegistry(testSchemas)
+	if err != nil {
+		t.Fatalf("NewFSSchemaRegistry: %v", err)
+	}
+
+	tests := map[string]struct {
+		msgType, version, body string
+		want                   error
+	}{
+		"valid":           {"order.created", "v1", `{"id":"42"}`, nil},
+		"missing field":   {"order.created", "v1", `{}`, ErrInvalidMessage},
+		"not JSON":        {"order.created", "v1", `{`, ErrInvalidMessage},
+		"valid $ref":      {"order.created", "v2", `{"id":"42","total":{"amount":100,"currency":"BRL"}}`, nil},
+		"invalid $ref":    {"order.created", "v2", `{"id":"42","total":{"amount":-1,"currency":"BRL"}}`, ErrInvalidMessage},
+		"unknown version": {"order.created", "v3", `{}`, ErrSchemaNotFound},
+		"unknown type":    {"order.deleted", "v1", `{}`, ErrSchemaNotFound},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := registry.Validate(context.Background(), tt.msgType, tt.version, []byte(tt.body))
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFSSchemaRegistryRejectsInvalidSchemas(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"malformed JSON": {"order.created/v1.json": {Data: []byte(`{`)}},
+		"invalid schema": {"order.created/v1.json": {Data: []byte(`{"type": 42}`)}},
+		"missing $ref":   {"order.created/v1.json": {Data: []byte(`{"$ref": "../common/missing.json"}`)}},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewFSSchemaRegistry(fsys); err == nil {
+				t.Fatal("NewFSSchemaRegistry succeeded")
+			}
+		})
+	}
+}
+
+func TestSchemaValidator(t *testing.T) {
+	registry, err := NewFSSchemaRegistry(testSchemas)
+	if err != nil {
+		t.Fatalf("NewFSSchemaRegistry: %v", err)
+	}
+
+	tests := map[string]struct {
+		strict  bool
+		msgType string
+		version string
+		body    string
+		want    error
+	}{
+		"default version":          {msgType: "order.created", body: `{"id":"42"}`},
+		"explicit version":         {msgType: "order.created", version: "v2", body: `{"id":"42"}`, want: ErrInvalidMessage},
+		"no type":                  {body: `{}`},
+		"no type, strict":          {strict: true, body: `{}`, want: ErrInvalidMessage},
+		"unknown schema":           {msgType: "order.deleted", body: `{}`},
+		"unknown schema, strict":   {strict: true, msgType: "order.deleted", body: `{}`, want: ErrInvalidMessage},
+		"invalid message, lenient": {msgType: "order.created", body: `{}`, want: ErrInvalidMessage},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := newSchemaValidator(SchemaCfg{Registry: registry, Strict: tt.strict})
+			msg := amqp091.Publishing{Type: tt.msgType, Body: []byte(tt.body)}
+			if tt.version != "" {
+				msg.Headers = amqp091.Table{headerSchemaVersion: tt.version}
+			}
+
+			err := v.validatePublishing(context.Background(), &msg)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("validatePublishing = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaValidatorDeadLettersInvalidDeliveries(t *testing.T) {
+	registry, err := NewFSSchemaRegistry(testSchemas)
+	if err != nil {
+		t.Fatalf("NewFSSchemaRegistry: %v", err)
+	}
+	v := newSchemaValidator(SchemaCfg{Registry: registry})
+
+	invalid := amqp091.Delivery{Type: "order.created", Body: []byte(`{}`)}
+	if err := v.validateDelivery(context.Background(), &invalid); !errors.Is(err, ErrDeadLetter) {
+		t.Fatalf("validateDelivery = %v, want ErrDeadLetter", err)
+	}
+
+	v = newSchemaValidator(SchemaCfg{Registry: failingRegistry{}})
+	if err := v.validateDelivery(context.Background(), &invalid); !errors.Is(err, errHandler) || errors.Is(err, ErrDeadLetter) {
+		t.Fatalf("validateDelivery = %v, want the registry error without ErrDeadLetter", err)
+	}
+
+	var nilValidator *schemaValidator
+	if err := nilValidator.validateDelivery(context.Background(), &invalid); err != nil {
+		t.Fatalf("nil validator = %v, want nil", err)
+	}
+}
@@ -0,0 +1,237 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// shardWeight is the binding weight of every shard queue, so keys spread evenly.
+const shardWeight = "1"
+
+// ShardQueueName returns the name of a shard queue of a sharded exchange:
+// <exchange>.shard.<shard>.
+func ShardQueueName(exchange string, shard int) string {
+	return fmt.Sprintf("%s.shard.%d", exchange, shard)
+}
+
+// shardQueueArgs are the arguments shard queues are declared with: a single active
+// consumer at a time keeps the messages of a hash key in order.
+func shardQueueArgs() amqp091.Table {
+	return amqp091.Table{"x-single-active-consumer": true}
+}
+
+// DeclareShardedQueues declares a durable x-consistent-hash exchange and shards queues
+// bound to it, named with ShardQueueName. Messages published to the exchange with
+// PublishToExchange are routed by the hash of their routing key, so all messages with
+// the same key land in the same shard, in order. Requires the
+// rabbitmq_consistent_hash_exchange plugin.
+//
+// Changing the number of shards moves part of the keys to other shards, so drain the
+// queues before resharding when ordering matters.
+func (k *client) DeclareShardedQueues(exchange string, shards int) error {
+	if exchange == "" {
+		return errors.New("exchange name cannot be empty")
+	}
+	if shards <= 0 {
+		return errors.New("shards must be greater than 0")
+	}
+
+	err := k.withChannel(func(ch *amqp091.Channel) error {
+		if err := ch.ExchangeDeclare(exchange, "x-consistent-hash", true, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare exchange %q: %w", exchange, err)
+		}
+		for shard := range shards {
+			name := ShardQueueName(exchange, shard)
+			if _, err := ch.QueueDeclare(name, true, false, false, false, shardQueueArgs()); err != nil {
+				return fmt.Errorf("failed to declare shard queue %q: %w", name, err)
+			}
+			if err := ch.QueueBind(name, shardWeight, exchange, false, nil); err != nil {
+				return fmt.Errorf("failed to bind shard queue %q: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for shard := range shards {
+		k.queues.set(ShardQueueName(exchange, shard), shardQueueArgs())
+	}
+
+	return nil
+}
+
+// ShardConsumerCfg selects the shards an instance consumes.
+type ShardConsumerCfg struct {
+	// Shards is the number of shards the exchange was declared with. Required.
+	Shards int
+	// Claim lists the shards this instance subscribes to, from 0 to Shards-1.
+	// Defaults to every shard.
+	//
+	// Each shard is consumed by a single active consumer; the subscriptions of other
+	// instances stay on standby and take over when it goes away. The broker activates
+	// the first subscription, so to spread shards across instances give each instance
+	// a distinct subset, and overlap the subsets for failover.
+	Claim []int
+}
+
+// NewShardConsumer creates a consumer that processes the claimed shard queues of a
+// sharded exchange with handler. Consume(concurrency) runs concurrency workers per
+// shard; use 1 to process the messages of each hash key in order.
+func (k *client) NewShardConsumer(consumerName, exchange string, handler ConsumerHandler, cfg ShardConsumerCfg, opts ...ConsumerOption) (Consumer, error) {
+	if handler == nil {
+		return nil, errors.New("handler cannot be nil")
+	}
+	shards, err := claimedShards(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	consumers := make([]Consumer, len(shards))
+	for i, shard := range shards {
+		name := ShardQueueName(exchange, shard)
+		// Consumers may run in processes that did not declare the queues
+		k.queues.set(name, shardQueueArgs())
+		consumers[i] = k.newConsumer(fmt.Sprintf("%s-%d", consumerName, shard), name, handler, opts)
+	}
+
+	return NewShardGroup(consumers...), nil
+}
+
+// claimedShards validates cfg and returns the shards to consume.
+func claimedShards(cfg ShardConsumerCfg) ([]int, error) {
+	if cfg.Shards <= 0 {
+		return nil, errors.New("shards must be greater than 0")
+	}
+	if len(cfg.Claim) == 0 {
+		shards := make([]int, cfg.Shards)
+		for i := range shards {
+			shards[i] = i
+		}
+		return shards, nil
+	}
+
+	shards := slices.Compact(slices.Sorted(slices.Values(cfg.Claim)))
+	for _, shard := range shards {
+		if shard < 0 || shard >= cfg.Shards {
+			return nil, fmt.Errorf("claimed shard %d is out of range [0, %d)", shard, cfg.Shards)
+		}
+	}
+	return shards, nil
+}
+
+// ShardGroup runs the consumers of several shard queues as a single Consumer.
+type ShardGroup struct {
+	consumers []Consumer
+	mu        sync.Mutex
+	running   []bool
+	errs      []error
+	exited    chan struct{}
+}
+
+var _ Consumer = (*ShardGroup)(nil)
+
+// NewShardGroup groups consumers, typically one per shard queue, so they start, pause
+// and stop together.
+func NewShardGroup(consumers ...Consumer) *ShardGroup {
+	return &ShardGroup{
+		consumers: consumers,
+		running:   make([]bool, len(consumers)),
+		errs:      make([]error, len(consumers)),
+		exited:    make(chan struct{}),
+	}
+}
+
+// Consume starts every consumer that is not already running with concurrency and
+// blocks until one of them returns, then returns the errors of the consumers that
+// exited. The other consumers keep running, so calling Consume again, as the Router
+// does when it restarts a consumer, restarts only the shards that exited.
+func (g *ShardGroup) Consume(concurrency int) error {
+	if concurrency <= 0 {
+		return fmt.Errorf("concurrency must be greater than 0")
+	}
+	if len(g.consumers) == 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	for i, c := range g.consumers {
+		if g.running[i] {
+			continue
+		}
+		g.running[i] = true
+		g.errs[i] = nil
+		go g.consume(i, c, concurrency)
+	}
+	exited := g.exited
+	g.mu.Unlock()
+
+	<-exited
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var errs []error
+	for i, err := range g.errs {
+		if !g.running[i] && err != nil {
+			errs = append(errs, fmt.Errorf("shard consumer %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// consume runs the consumer at index i and wakes up Consume when it returns.
+func (g *ShardGroup) consume(i int, c Consumer, concurrency int) {
+	err := c.Consume(concurrency)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.running[i] = false
+	g.errs[i] = err
+	close(g.exited)
+	g.exited = make(chan struct{})
+}
+
+// Pause pauses every consumer.
+func (g *ShardGroup) Pause() {
+	for _, c := range g.consumers {
+		c.Pause()
+	}
+}
+
+// Resume resumes every consumer.
+func (g *ShardGroup) Resume() {
+	for _, c := range g.consumers {
+		c.Resume()
+	}
+}
+
+// IsPaused reports whether every consumer is paused.
+func (g *ShardGroup) IsPaused() bool {
+	for _, c := range g.consumers {
+		if !c.IsPaused() {
+			return false
+		}
+	}
+	return len(g.consumers) > 0
+}
+
+// Stop stops every consumer in parallel and waits for them, or until ctx is done.
+func (g *ShardGroup) Stop(ctx context.Context) error {
+	errs := make([]error, len(g.consumers))
+	var wg sync.WaitGroup
+	for i, c := range g.consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.Stop(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
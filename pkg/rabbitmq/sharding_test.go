@@ -0,0 +1,37 @@
+package rabbitmq
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClaimedShards(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cfg     ShardConsumerCfg
+		want    []int
+		wantErr bool
+	}{
+		{name: "every shard by default", cfg: ShardConsumerCfg{Shards: 3}, want: []int{0, 1, 2}},
+		{name: "sorted and deduplicated claim", cfg: ShardConsumerCfg{Shards: 4, Claim: []int{3, 1, 3}}, want: []int{1, 3}},
+		{name: "no shards", cfg: ShardConsumerCfg{}, wantErr: true},
+		{name: "claim out of range", cfg: ShardConsumerCfg{Shards: 2, Claim: []int{2}}, wantErr: true},
+		{name: "negative claim", cfg: ShardConsumerCfg{Shards: 2, Claim: []int{-1}}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := claimedShards(tc.cfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("claimedShards error = %v, want error %t", err, tc.wantErr)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("claimedShards = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestShardQueueName(t *testing.T) {
+	if got := ShardQueueName("orders", 2); got != "orders.shard.2" {
+		t.Fatalf("ShardQueueName = %q, want orders.shard.2", got)
+	}
+}
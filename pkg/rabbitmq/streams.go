@@ -0,0 +1,450 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	defaultStreamCommitInterval = 100
+	defaultStreamRetryDelay     = 5 * time.Second
+
+	streamOffsetArg = "x-stream-offset"
+)
+
+// StreamCfg holds the retention settings of a stream queue. Zero values leave the
+// broker defaults in place.
+type StreamCfg struct {
+	// MaxAge discards segments older than this duration (x-max-age).
+	MaxAge time.Duration
+	// MaxLengthBytes caps the total size of the stream (x-max-length-bytes).
+	MaxLengthBytes int64
+	// MaxSegmentSizeBytes is the size of each segment file on disk (x-stream-max-segment-size-bytes).
+	MaxSegmentSizeBytes int64
+}
+
+// arguments returns the queue declaration arguments for the stream.
+func (cfg StreamCfg) arguments() amqp091.Table {
+	args := amqp091.Table{"x-queue-type": "stream"}
+	if cfg.MaxAge > 0 {
+		args["x-max-age"] = strconv.FormatInt(max(int64(cfg.MaxAge/time.Second), 1), 10) + "s"
+	}
+	if cfg.MaxLengthBytes > 0 {
+		args["x-max-length-bytes"] = cfg.MaxLengthBytes
+	}
+	if cfg.MaxSegmentSizeBytes > 0 {
+		args["x-stream-max-segment-size-bytes"] = cfg.MaxSegmentSizeBytes
+	}
+	return args
+}
+
+// DeclareStream declares a durable stream queue with the given retention settings.
+// Producers and consumers created from this client declare the queue with the same
+// arguments, so call it before publishing to or consuming from the stream.
+func (k *client) DeclareStream(name string, cfg StreamCfg) error {
+	if name == "" {
+		return errors.New("stream name cannot be empty")
+	}
+
+	if err := k.declareQueue(name, cfg.arguments()); err != nil {
+		return fmt.Errorf("error declaring stream: %w", err)
+	}
+	return nil
+}
+
+// StreamOffset is the position a stream consumer starts reading from.
+// The zero value is equivalent to StreamOffsetNext.
+type StreamOffset struct {
+	value any
+}
+
+// StreamOffsetFirst starts from the first message still available in the stream.
+func StreamOffsetFirst() StreamOffset { return StreamOffset{value: "first"} }
+
+// StreamOffsetLast starts from the last written chunk of messages.
+func StreamOffsetLast() StreamOffset { return StreamOffset{value: "last"} }
+
+// StreamOffsetNext starts with the next message written after subscribing.
+func StreamOffsetNext() StreamOffset { return StreamOffset{value: "next"} }
+
+// StreamOffsetAt starts at the given numeric offset.
+func StreamOffsetAt(offset int64) StreamOffset { return StreamOffset{value: offset} }
+
+// StreamOffsetAtTime starts at the first chunk written at or after t.
+// The broker stores timestamps with second precision.
+func StreamOffsetAtTime(t time.Time) StreamOffset { return StreamOffset{value: t} }
+
+// Value returns the x-stream-offset consume argument: "first", "last", "next", an
+// int64 offset or a time.Time.
+func (o StreamOffset) Value() any {
+	if o.value == nil {
+		return "next"
+	}
+	return o.value
+}
+
+// OffsetStore persists the last processed offset of stream consumers.
+type OffsetStore interface {
+	// LoadOffset returns the last processed offset of the consumer on the stream,
+	// and false when none was saved.
+	LoadOffset(ctx context.Context, stream, consumerName string) (int64, bool, error)
+	// SaveOffset stores the last processed offset of the consumer on the stream.
+	SaveOffset(ctx context.Context, stream, consumerName string, offset int64) error
+}
+
+// MemoryOffsetStore keeps offsets in memory. It is useful in tests and for consumers
+// that only need to resume after Pause or a resubscription within the same process.
+type MemoryOffsetStore struct {
+	mu      sync.Mutex
+	offsets map[string]int64
+}
+
+var _ OffsetStore = (*MemoryOffsetStore)(nil)
+
+// NewMemoryOffsetStore creates an empty in-memory offset store.
+func NewMemoryOffsetStore() *MemoryOffsetStore {
+	return &MemoryOffsetStore{offsets: make(map[string]int64)}
+}
+
+// LoadOffset returns the saved offset, if any.
+func (s *MemoryOffsetStore) LoadOffset(_ context.Context, stream, consumerName string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset, ok := s.offsets[stream+"/"+consumerName]
+	return offset, ok, nil
+}
+
+// SaveOffset stores the offset.
+func (s *MemoryOffsetStore) SaveOffset(_ context.Context, stream, consumerName string, offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offsets[stream+"/"+consumerName] = offset
+	return nil
+}
+
+// FileOffsetStore keeps one file per stream and consumer in a directory.
+// Files are replaced atomically, so a crash never leaves a partial offset behind.
+type FileOffsetStore struct {
+	dir string
+}
+
+var _ OffsetStore = (*FileOffsetStore)(nil)
+
+// NewFileOffsetStore creates a file offset store in dir, creating it if needed.
+func NewFileOffsetStore(dir string) (*FileOffsetStore, error) {
+	if dir == "" {
+		return nil, errors.New("directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating offset directory: %w", err)
+	}
+	return &FileOffsetStore{dir: dir}, nil
+}
+
+// LoadOffset reads the saved offset, if any.
+func (s *FileOffsetStore) LoadOffset(_ context.Context, stream, consumerName string) (int64, bool, error) {
+	data, err := os.ReadFile(s.path(stream, consumerName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading offset: %w", err)
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("error parsing offset: %w", err)
+	}
+	return offset, true, nil
+}
+
+// SaveOffset writes the offset.
+func (s *FileOffsetStore) SaveOffset(_ context.Context, stream, consumerName string, offset int64) error {
+	path := s.path(stream, consumerName)
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating offset file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // no-op once renamed
+
+	if _, err := tmp.WriteString(strconv.FormatInt(offset, 10)); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing offset: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing offset: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving offset: %w", err)
+	}
+	return nil
+}
+
+func (s *FileOffsetStore) path(stream, consumerName string) string {
+	return filepath.Join(s.dir, url.PathEscape(stream)+"."+url.PathEscape(consumerName)+".offset")
+}
+
+// StreamConsumerCfg configures a stream consumer.
+type StreamConsumerCfg struct {
+	// Offset is where consumption starts when Store has no saved offset. Defaults to StreamOffsetNext.
+	Offset StreamOffset
+	// Store persists the last processed offset so consumption resumes after it on restart. Optional.
+	Store OffsetStore
+	// CommitInterval is the number of processed messages between offset saves. Offsets
+	// are also saved whenever a subscription ends. Defaults to 100.
+	CommitInterval int
+	// RetryDelay is how long to wait before reading a message again after its handler failed.
+	// Defaults to 5 seconds.
+	RetryDelay time.Duration
+}
+
+type streamConsumer struct {
+	*consumer
+	start          StreamOffset
+	store          OffsetStore
+	commitInterval int
+	retryDelay     time.Duration
+	mu             sync.Mutex
+	next           *int64
+	last           int64
+	uncommitted    int
+}
+
+// NewStreamConsumer creates a consumer for a stream queue.
+//
+// Stream messages are not removed when acknowledged, so the consumer tracks the offset
+// of the last processed message itself and saves it to cfg.Store. Messages are handled
+// one at a time, in offset order; when the handler fails, the subscription is renewed
+// from the failed message after cfg.RetryDelay, unless the handler returned ErrDeadLetter,
+// in which case the message is skipped. The concurrency passed to Consume is
+// used as the prefetch count.
+//
+// If the stream was not declared with DeclareStream on this client, it must already exist.
+func (k *client) NewStreamConsumer(consumerName, streamName string, handler ConsumerHandler, cfg StreamConsumerCfg, opts ...ConsumerOption) (Consumer, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("handler cannot be nil")
+	}
+
+	s := &streamConsumer{
+		consumer:       k.newConsumer(consumerName, streamName, handler, opts),
+		start:          cfg.Offset,
+		store:          cfg.Store,
+		commitInterval: cfg.CommitInterval,
+		retryDelay:     cfg.RetryDelay,
+	}
+	if s.commitInterval <= 0 {
+		s.commitInterval = defaultStreamCommitInterval
+	}
+	if s.retryDelay <= 0 {
+		s.retryDelay = defaultStreamRetryDelay
+	}
+	s.stream = true
+	if _, ok := k.queues.get(streamName); !ok {
+		s.passiveDeclare = true
+	}
+
+	return s, nil
+}
+
+// Consume reads the stream until the client context is canceled, Stop is called or
+// the channel closes. prefetch bounds the number of unacknowledged deliveries.
+func (s *streamConsumer) Consume(prefetch int) error {
+	if prefetch <= 0 {
+		return fmt.Errorf("prefetch must be greater than 0")
+	}
+	if err := s.loadOffset(); err != nil {
+		return err
+	}
+
+	s.logger.Info("stream consumer started", slog.Int("prefetch", prefetch))
+
+	err := s.run(s.name, prefetch, s.consumeArgs, func(msgs <-chan amqp091.Delivery, done, interrupt <-chan struct{}) cycleEnd {
+		defer s.commit()
+
+		for {
+			select {
+			case <-done:
+				return cycleDone
+
+			case <-interrupt:
+				return cycleInterrupted
+
+			case msg, ok := <-msgs:
+				if !ok {
+					s.logger.Info("messages channel closed")
+					return cycleClosed
+				}
+				s.observe(&msg)
+
+				if err := s.waitRateLimit(done, interrupt); err != nil {
+					select {
+					case <-interrupt:
+						return cycleInterrupted
+					default:
+						return cycleDone
+					}
+				}
+
+				if s.handleDelivery(&msg) {
+					continue
+				}
+
+				// Read the failed message again on a new subscription
+				timer := time.NewTimer(s.retryDelay)
+				select {
+				case <-done:
+					timer.Stop()
+					return cycleDone
+				case <-timer.C:
+					return cycleInterrupted
+				}
+			}
+		}
+	})
+
+	s.logger.Info("stream consumer stopped")
+	return err
+}
+
+// handleDelivery processes one stream message and advances the offset on success.
+// It returns false when the handler failed and the message must be read again.
+func (s *streamConsumer) handleDelivery(msg *amqp091.Delivery) bool {
+	offset, hasOffset := deliveryOffset(msg)
+
+	handled := true
+	if len(msg.Body) == 0 {
+		s.ack(s.context, msg)
+	} else {
+		s.processDelivery(msg, func(ctx context.Context, err error) {
+			// Acknowledgements on streams only grant credit; the message stays in the log
+			s.ack(ctx, msg)
+			if err != nil {
+				s.logger.Log(ctx, s.errorLevel(err), "error handling message",
+					slog.String("error", err.Error()),
+					slog.Int64("offset", offset))
+				// Streams have no dead-letter exchange: dead-lettered messages are skipped
+				handled = errors.Is(err, ErrDeadLetter)
+			}
+		})
+	}
+
+	if !hasOffset {
+		return true
+	}
+	if !handled {
+		s.mu.Lock()
+		s.next = &offset
+		s.mu.Unlock()
+		return false
+	}
+
+	s.advance(offset)
+	return true
+}
+
+// consumeArgs returns the x-stream-offset argument for the next subscription: right
+// after the last processed message, at the first message seen, or the configured start
+// offset.
+func (s *streamConsumer) consumeArgs() amqp091.Table {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next != nil {
+		return amqp091.Table{streamOffsetArg: *s.next}
+	}
+	return amqp091.Table{streamOffsetArg: s.start.Value()}
+}
+
+// observe remembers the offset of the first delivery when nothing was processed yet,
+// so a subscription started from a relative offset ("next", "last" or a timestamp)
+// that is renewed by Pause or the circuit breaker resumes from it instead of skipping
+// the messages written in between.
+func (s *streamConsumer) observe(msg *amqp091.Delivery) {
+	offset, ok := deliveryOffset(msg)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next == nil {
+		s.next = &offset
+	}
+}
+
+// loadOffset resumes from the offset saved in the store, if any.
+func (s *streamConsumer) loadOffset() error {
+	if s.store == nil {
+		return nil
+	}
+
+	offset, ok, err := s.store.LoadOffset(s.context, s.queueName, s.name)
+	if err != nil {
+		return fmt.Errorf("error loading stream offset: %w", err)
+	}
+	if ok {
+		s.mu.Lock()
+		s.last = offset
+		next := offset + 1
+		s.next = &next
+		s.mu.Unlock()
+		s.logger.Info("resuming stream from saved offset", slog.Int64("offset", offset))
+	}
+	return nil
+}
+
+// advance records offset as processed and saves it every commitInterval messages.
+func (s *streamConsumer) advance(offset int64) {
+	s.mu.Lock()
+	s.last = offset
+	next := offset + 1
+	s.next = &next
+	s.uncommitted++
+	due := s.uncommitted >= s.commitInterval
+	s.mu.Unlock()
+
+	if due {
+		s.commit()
+	}
+}
+
+// commit saves the last processed offset to the store when it changed.
+func (s *streamConsumer) commit() {
+	if s.store == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.uncommitted == 0 {
+		s.mu.Unlock()
+		return
+	}
+	offset := s.last
+	s.uncommitted = 0
+	s.mu.Unlock()
+
+	// Offsets are also saved on shutdown, after the client context is canceled
+	ctx := context.WithoutCancel(s.context)
+	if err := s.store.SaveOffset(ctx, s.queueName, s.name, offset); err != nil {
+		s.logger.Error("failed to save stream offset",
+			slog.String("error", err.Error()),
+			slog.Int64("offset", offset))
+	}
+}
+
+// deliveryOffset returns the stream offset the broker attached to a delivery.
+func deliveryOffset(msg *amqp091.Delivery) (int64, bool) {
+	offset, ok := msg.Headers[streamOffsetArg].(int64)
+	return offset, ok
+}
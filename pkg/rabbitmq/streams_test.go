@@ -0,0 +1,154 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestStreamCfgArguments(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  StreamCfg
+		want amqp091.Table
+	}{
+		{
+			name: "defaults",
+			want: amqp091.Table{"x-queue-type": "stream"},
+		},
+		{
+			name: "retention",
+			cfg:  StreamCfg{MaxAge: 7 * 24 * time.Hour, MaxLengthBytes: 20 << 30, MaxSegmentSizeBytes: 100 << 20},
+			want: amqp091.Table{
+				"x-queue-type":                    "stream",
+				"x-max-age":                       "604800s",
+				"x-max-length-bytes":              int64(20 << 30),
+				"x-stream-max-segment-size-bytes": int64(100 << 20),
+			},
+		},
+		{
+			name: "sub-second max age",
+			cfg:  StreamCfg{MaxAge: time.Millisecond},
+			want: amqp091.Table{"x-queue-type": "stream", "x-max-age": "1s"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.cfg.arguments()
+			if len(got) != len(tc.want) {
+				t.Fatalf("arguments = %v, want %v", got, tc.want)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("%s = %v (%T), want %v (%T)", k, got[k], got[k], v, v)
+				}
+			}
+		})
+	}
+}
+
+func newTestStreamConsumer(t *testing.T, handler ConsumerHandler, cfg StreamConsumerCfg) *streamConsumer {
+	t.Helper()
+	c, err := newTestClient().NewStreamConsumer("projector", "events", handler, cfg)
+	if err != nil {
+		t.Fatalf("NewStreamConsumer: %v", err)
+	}
+	return c.(*streamConsumer)
+}
+
+func streamDelivery(offset int64) amqp091.Delivery {
+	return amqp091.Delivery{Headers: amqp091.Table{streamOffsetArg: offset}, Body: []byte("{}")}
+}
+
+func TestStreamConsumerResumesFromFirstSeenOffset(t *testing.T) {
+	s := newTestStreamConsumer(t, testHandler(func([]byte) error { return nil }), StreamConsumerCfg{})
+
+	if got := s.consumeArgs()[streamOffsetArg]; got != "next" {
+		t.Fatalf("first subscription offset = %v, want next", got)
+	}
+
+	// A delivery taken but not processed before the subscription is renewed
+	msg := streamDelivery(42)
+	s.observe(&msg)
+	if got := s.consumeArgs()[streamOffsetArg]; got != int64(42) {
+		t.Fatalf("offset after a seen delivery = %v, want 42", got)
+	}
+
+	s.handleDelivery(&msg)
+	later := streamDelivery(50)
+	s.observe(&later)
+	if got := s.consumeArgs()[streamOffsetArg]; got != int64(43) {
+		t.Fatalf("offset after processing 42 = %v, want 43", got)
+	}
+}
+
+func TestStreamConsumerRetriesFailedMessage(t *testing.T) {
+	errDown := errors.New("dependency down")
+	s := newTestStreamConsumer(t, testHandler(func([]byte) error { return errDown }), StreamConsumerCfg{})
+
+	msg := streamDelivery(7)
+	if s.handleDelivery(&msg) {
+		t.Fatal("failed message reported as handled")
+	}
+	if got := s.consumeArgs()[streamOffsetArg]; got != int64(7) {
+		t.Fatalf("offset after a failure = %v, want 7", got)
+	}
+}
+
+func TestStreamConsumerSkipsDeadLetters(t *testing.T) {
+	s := newTestStreamConsumer(t, testHandler(func([]byte) error { return ErrDeadLetter }), StreamConsumerCfg{})
+
+	msg := streamDelivery(7)
+	if !s.handleDelivery(&msg) {
+		t.Fatal("dead-lettered message not skipped")
+	}
+	if got := s.consumeArgs()[streamOffsetArg]; got != int64(8) {
+		t.Fatalf("offset after a dead letter = %v, want 8", got)
+	}
+}
+
+func TestStreamConsumerCommitsOffsets(t *testing.T) {
+	store := NewMemoryOffsetStore()
+	s := newTestStreamConsumer(t, testHandler(func([]byte) error { return nil }), StreamConsumerCfg{Store: store, CommitInterval: 2})
+
+	for offset := range int64(3) {
+		msg := streamDelivery(offset)
+		s.handleDelivery(&msg)
+	}
+	if offset, ok, _ := store.LoadOffset(context.Background(), "events", "projector"); !ok || offset != 1 {
+		t.Fatalf("saved offset = %d (%t), want 1 after CommitInterval messages", offset, ok)
+	}
+
+	s.commit()
+	if offset, _, _ := store.LoadOffset(context.Background(), "events", "projector"); offset != 2 {
+		t.Fatalf("saved offset = %d, want 2 after commit", offset)
+	}
+
+	resumed := newTestStreamConsumer(t, testHandler(func([]byte) error { return nil }), StreamConsumerCfg{Store: store})
+	if err := resumed.loadOffset(); err != nil {
+		t.Fatalf("loadOffset: %v", err)
+	}
+	if got := resumed.consumeArgs()[streamOffsetArg]; got != int64(3) {
+		t.Fatalf("resumed offset = %v, want 3", got)
+	}
+}
+
+func TestFileOffsetStore(t *testing.T) {
+	store, err := NewFileOffsetStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileOffsetStore: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, ok, err := store.LoadOffset(ctx, "events", "projector"); ok || err != nil {
+		t.Fatalf("LoadOffset on an empty store = %t, %v", ok, err)
+	}
+	if err := store.SaveOffset(ctx, "events", "projector/1", 99); err != nil {
+		t.Fatalf("SaveOffset: %v", err)
+	}
+	if offset, ok, err := store.LoadOffset(ctx, "events", "projector/1"); !ok || err != nil || offset != 99 {
+		t.Fatalf("LoadOffset = %d, %t, %v, want 99", offset, ok, err)
+	}
+}
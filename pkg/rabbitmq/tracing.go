@@ -0,0 +1,128 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/zarvhq/zarv-go/pkg/rabbitmq"
+
+// headerCarrier adapts AMQP message headers to a propagation.TextMapCarrier.
+type headerCarrier amqp091.Table
+
+var _ propagation.TextMapCarrier = headerCarrier(nil)
+
+// Get returns the string value stored under key, or an empty string.
+func (h headerCarrier) Get(key string) string {
+	switch v := h[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+// Set stores value under key.
+func (h headerCarrier) Set(key, value string) {
+	h[key] = value
+}
+
+// Keys lists the header keys.
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// messagingAttributes returns the semantic-convention attributes shared by producer and
+// consumer spans. For RabbitMQ the destination is the exchange, empty for the default
+// exchange, and the routing key is recorded separately.
+func messagingAttributes(exchange, routingKey, messageID string, bodySize int) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemRabbitMQ,
+		semconv.MessagingDestinationName(exchange),
+		semconv.MessagingRabbitMQDestinationRoutingKey(routingKey),
+		semconv.MessagingMessageBodySize(bodySize),
+	}
+	if messageID != "" {
+		attrs = append(attrs, semconv.MessagingMessageID(messageID))
+	}
+	return attrs
+}
+
+// startPublishSpan starts a producer span and injects its context into the message
+// headers. It must be called before the body is encoded, so the span records the
+// payload size. The span is named after the exchange, or the queue for the default one.
+func startPublishSpan(ctx context.Context, tracer trace.Tracer, propagator propagation.TextMapPropagator, exchange, routingKey string, msg *amqp091.Publishing) (context.Context, trace.Span) {
+	attrs := append(messagingAttributes(exchange, routingKey, msg.MessageId, len(msg.Body)),
+		semconv.MessagingOperationTypeSend,
+		semconv.MessagingOperationName("publish"),
+	)
+
+	destination := exchange
+	if destination == "" {
+		destination = routingKey
+	}
+	ctx, span := tracer.Start(ctx, "publish "+destination,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrs...),
+	)
+
+	if msg.Headers == nil {
+		msg.Headers = amqp091.Table{}
+	}
+	propagator.Inject(ctx, headerCarrier(msg.Headers))
+
+	return ctx, span
+}
+
+// startProcessSpan extracts the trace context from the delivery headers and starts a consumer span.
+func startProcessSpan(ctx context.Context, tracer trace.Tracer, propagator propagation.TextMapPropagator, queueName, consumerName string, d *amqp091.Delivery, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if d.Headers != nil {
+		ctx = propagator.Extract(ctx, headerCarrier(d.Headers))
+	}
+
+	attrs := append(messagingAttributes(d.Exchange, d.RoutingKey, d.MessageId, len(d.Body)),
+		semconv.MessagingOperationTypeProcess,
+		semconv.MessagingOperationName("process"),
+		semconv.MessagingDestinationSubscriptionName(queueName),
+		semconv.MessagingConsumerGroupName(consumerName),
+		semconv.MessagingRabbitMQMessageDeliveryTag(int(d.DeliveryTag)), //nolint:gosec // delivery tags fit in int on 64-bit platforms
+	)
+
+	opts = append([]trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	}, opts...)
+	return tracer.Start(ctx, "process "+queueName, opts...)
+}
+
+// recordSpanError marks the span as failed when err is not nil.
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// newMessageID returns a random identifier for outgoing messages.
+func newMessageID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
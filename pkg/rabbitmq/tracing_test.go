@@ -0,0 +1,155 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecordingClient(t *testing.T, opts ...ClientOption) (*client, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return newTestClient(append([]ClientOption{WithTracerProvider(tp)}, opts...)...), recorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func assertAttr(t *testing.T, span sdktrace.ReadOnlySpan, want attribute.KeyValue) {
+	t.Helper()
+	got, ok := spanAttr(span, want.Key)
+	if !ok {
+		t.Errorf("span %q: missing attribute %s", span.Name(), want.Key)
+		return
+	}
+	if got != want.Value {
+		t.Errorf("span %q: %s = %v, want %v", span.Name(), want.Key, got.Emit(), want.Value.Emit())
+	}
+}
+
+func TestPublishAndProcessSpansAreLinked(t *testing.T) {
+	c, recorder := newRecordingClient(t)
+
+	msg := amqp091.Publishing{MessageId: "msg-1", Body: []byte(`{"id":1}`)}
+	_, publishSpan := startPublishSpan(context.Background(), c.tracer, c.propagator, "", "orders", &msg)
+	publishSpan.End()
+
+	if msg.Headers["traceparent"] == nil {
+		t.Fatal("trace context not injected into the message headers")
+	}
+
+	delivery := amqp091.Delivery{
+		Headers:     msg.Headers,
+		MessageId:   msg.MessageId,
+		Body:        msg.Body,
+		RoutingKey:  "orders",
+		DeliveryTag: 7,
+	}
+	consumer := c.newConsumer("worker", "orders", testHandler(func([]byte) error { return nil }), nil)
+	consumer.processDelivery(&delivery, func(context.Context, error) {})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	publish, process := spans[0], spans[1]
+
+	if publish.Name() != "publish orders" || publish.SpanKind() != trace.SpanKindProducer {
+		t.Errorf("publish span = %q (%v)", publish.Name(), publish.SpanKind())
+	}
+	if process.Name() != "process orders" || process.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("process span = %q (%v)", process.Name(), process.SpanKind())
+	}
+	if process.Parent().SpanID() != publish.SpanContext().SpanID() {
+		t.Error("process span is not a child of the publish span")
+	}
+	if process.SpanContext().TraceID() != publish.SpanContext().TraceID() {
+		t.Error("process span does not share the publish trace")
+	}
+
+	for _, span := range spans {
+		assertAttr(t, span, semconv.MessagingSystemRabbitMQ)
+		assertAttr(t, span, semconv.MessagingDestinationName(""))
+		assertAttr(t, span, semconv.MessagingRabbitMQDestinationRoutingKey("orders"))
+		assertAttr(t, span, semconv.MessagingMessageID("msg-1"))
+		assertAttr(t, span, semconv.MessagingMessageBodySize(len(msg.Body)))
+	}
+	assertAttr(t, publish, semconv.MessagingOperationName("publish"))
+	assertAttr(t, publish, semconv.MessagingOperationTypeSend)
+	assertAttr(t, process, semconv.MessagingOperationName("process"))
+	assertAttr(t, process, semconv.MessagingOperationTypeProcess)
+	assertAttr(t, process, semconv.MessagingDestinationSubscriptionName("orders"))
+	assertAttr(t, process, semconv.MessagingConsumerGroupName("worker"))
+	assertAttr(t, process, semconv.MessagingRabbitMQMessageDeliveryTag(7))
+}
+
+func TestPublishSpanNamedAfterExchange(t *testing.T) {
+	c, recorder := newRecordingClient(t)
+
+	msg := amqp091.Publishing{}
+	_, span := startPublishSpan(context.Background(), c.tracer, c.propagator, "orders", "customer-1", &msg)
+	span.End()
+
+	publish := recorder.Ended()[0]
+	if publish.Name() != "publish orders" {
+		t.Errorf("span name = %q, want %q", publish.Name(), "publish orders")
+	}
+	assertAttr(t, publish, semconv.MessagingDestinationName("orders"))
+	assertAttr(t, publish, semconv.MessagingRabbitMQDestinationRoutingKey("customer-1"))
+}
+
+func TestPublishSpanRecordsPayloadSize(t *testing.T) {
+	c, recorder := newRecordingClient(t, WithCompression(CompressionGzip, 0))
+	p := c.newProducer()
+
+	payload := map[string]string{"data": string(make([]byte, 4096))}
+	// Without a connection the publish fails after encoding the body
+	if err := p.PublishWithContext(context.Background(), "orders", payload); err == nil {
+		t.Fatal("expected publish without a connection to fail")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	size, _ := spanAttr(spans[0], semconv.MessagingMessageBodySizeKey)
+	if size.AsInt64() < 4096 {
+		t.Errorf("body size = %d, want the uncompressed payload size", size.AsInt64())
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("span status = %v, want error", spans[0].Status().Code)
+	}
+}
+
+func TestProcessSpanRecordsHandlerError(t *testing.T) {
+	c, recorder := newRecordingClient(t)
+	handlerErr := errors.New("boom")
+
+	var settled error
+	consumer := c.newConsumer("worker", "orders", testHandler(func([]byte) error { return handlerErr }), nil)
+	consumer.processDelivery(&amqp091.Delivery{Body: []byte("{}")}, func(_ context.Context, err error) { settled = err })
+
+	if !errors.Is(settled, handlerErr) {
+		t.Errorf("settled with %v, want %v", settled, handlerErr)
+	}
+	span := recorder.Ended()[0]
+	if span.Status().Code != codes.Error || len(span.Events()) == 0 {
+		t.Errorf("span status = %v with %d events, want an error with a recorded event", span.Status().Code, len(span.Events()))
+	}
+}